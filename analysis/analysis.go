@@ -0,0 +1,162 @@
+// Package analysis computes the difficulty metrics of the bomb layout: 3BV, openings, numbered islands
+// and whether the board can be cleared by logic alone
+package analysis
+
+import (
+	"sort"
+
+	"github.com/miner/solver"
+)
+
+// Layout is the bomb layout of the board. Cells are numbered column by column
+type Layout struct {
+	Width  int
+	Height int
+	Mines  []bool
+}
+
+// Stats is the difficulty of the board
+type Stats struct {
+	// BBBV is the minimum number of clicks needed to clear the board without flags
+	BBBV int
+	// Openings is the size of every opening, the empty cells together with their numbered border, largest first
+	Openings []int
+	// Islands is the size of every group of the numbered cells not bordering an opening, largest first
+	Islands []int
+	// NeedsGuess reports whether the solver has to guess to clear the board from the start cell
+	NeedsGuess bool
+}
+
+// Analyze computes the stats of the layout. The guesses are checked from the start cell,
+// a negative start means the largest opening
+func Analyze(l Layout, start int) Stats {
+	counts := l.counts()
+	openings, islands := regions(l, counts)
+	s := Stats{BBBV: len(openings)}
+	pick, largest := start < 0, 0
+	for _, region := range openings {
+		s.Openings = append(s.Openings, len(region))
+		if pick && len(region) > largest {
+			start, largest = region[0], len(region)
+		}
+	}
+	for _, region := range islands {
+		s.Islands = append(s.Islands, len(region))
+		s.BBBV += len(region)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(s.Openings)))
+	sort.Sort(sort.Reverse(sort.IntSlice(s.Islands)))
+	// without an opening and a start cell the first click is a guess
+	s.NeedsGuess = true
+	if start >= 0 {
+		_, solved := Solve(l, start)
+		s.NeedsGuess = !solved
+	}
+	return s
+}
+
+// BBBV returns the 3BV of the layout: every opening counts once and every numbered cell not bordering an opening counts once
+func BBBV(l Layout) int {
+	openings, islands := regions(l, l.counts())
+	bbbv := len(openings)
+	for _, region := range islands {
+		bbbv += len(region)
+	}
+	return bbbv
+}
+
+// Solve plays the layout from the start cell with the solver. Returns the board the solver stopped at and whether it is cleared
+func Solve(l Layout, start int) (solver.Board, bool) {
+	counts := l.counts()
+	board := solver.NewBoard(l.Width, l.Height)
+	left := len(l.Mines)
+	for _, mine := range l.Mines {
+		if mine {
+			left--
+		}
+	}
+	mines := len(l.Mines) - left
+	near := make([]int, 0, 8)
+	open := func(i int) {
+		stack := []int{i}
+		for len(stack) > 0 {
+			i := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if board.Cells[i] != solver.Unknown {
+				continue
+			}
+			board.Cells[i] = counts[i]
+			left--
+			if counts[i] == 0 {
+				stack = append(stack, board.Neighbours(i, near[:0])...)
+			}
+		}
+	}
+	if l.Mines[start] {
+		return board, false
+	}
+	open(start)
+	for left > 0 {
+		r := solver.Solve(board, mines)
+		if len(r.Safe) == 0 {
+			return board, false
+		}
+		for _, cell := range r.Mines {
+			board.Cells[cell.X*l.Height+cell.Y] = solver.Flagged
+		}
+		for _, cell := range r.Safe {
+			open(cell.X*l.Height + cell.Y)
+		}
+	}
+	return board, true
+}
+
+// counts returns the number of mines around every cell
+func (l Layout) counts() []int {
+	board := solver.Board{Width: l.Width, Height: l.Height}
+	counts := make([]int, len(l.Mines))
+	near := make([]int, 0, 8)
+	for i, mine := range l.Mines {
+		if !mine {
+			continue
+		}
+		for _, n := range board.Neighbours(i, near[:0]) {
+			counts[n]++
+		}
+	}
+	return counts
+}
+
+// regions splits the safe cells into the openings with their numbered border and the islands of the other numbered cells
+func regions(l Layout, counts []int) (openings, islands [][]int) {
+	board := solver.Board{Width: l.Width, Height: l.Height}
+	marked := make([]bool, len(l.Mines))
+	near := make([]int, 0, 8)
+	fill := func(i int, spread func(int) bool) []int {
+		region := []int{i}
+		marked[i] = true
+		for next := 0; next < len(region); next++ {
+			if !spread(region[next]) {
+				continue
+			}
+			for _, n := range board.Neighbours(region[next], near[:0]) {
+				if !marked[n] && !l.Mines[n] {
+					marked[n] = true
+					region = append(region, n)
+				}
+			}
+		}
+		return region
+	}
+	for i := range l.Mines {
+		if !marked[i] && !l.Mines[i] && counts[i] == 0 {
+			openings = append(openings, fill(i, func(j int) bool { return counts[j] == 0 }))
+		}
+	}
+	for i := range l.Mines {
+		if !marked[i] && !l.Mines[i] {
+			islands = append(islands, fill(i, func(int) bool { return true }))
+		}
+	}
+	return openings, islands
+}
@@ -0,0 +1,69 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+)
+
+// parseLayout builds the layout from rows, * is a mine
+func parseLayout(rows ...string) Layout {
+	l := Layout{Width: len(rows[0]), Height: len(rows), Mines: make([]bool, len(rows[0])*len(rows))}
+	for y, row := range rows {
+		for x, r := range row {
+			l.Mines[x*l.Height+y] = r == '*'
+		}
+	}
+	return l
+}
+
+func TestAnalyze(t *testing.T) {
+	tests := map[string]struct {
+		rows     []string
+		start    int
+		expected Stats
+	}{
+		"opening": {
+			rows:     []string{"*...."},
+			start:    -1,
+			expected: Stats{BBBV: 1, Openings: []int{4}},
+		},
+		"opening and island": {
+			rows:     []string{".*.."},
+			start:    -1,
+			expected: Stats{BBBV: 2, Openings: []int{2}, Islands: []int{1}},
+		},
+		"ring": {
+			rows:     []string{"...", ".*.", "..."},
+			start:    -1,
+			expected: Stats{BBBV: 8, Islands: []int{8}, NeedsGuess: true},
+		},
+		"guess": {
+			rows:     []string{"**...", "*....", "....."},
+			start:    -1,
+			expected: Stats{BBBV: 2, Openings: []int{11}, Islands: []int{1}, NeedsGuess: true},
+		},
+		"start on a number": {
+			rows:     []string{"*.", ".."},
+			start:    3,
+			expected: Stats{BBBV: 3, Islands: []int{3}, NeedsGuess: true},
+		},
+		"start on a mine": {
+			rows:     []string{"*...."},
+			start:    0,
+			expected: Stats{BBBV: 1, Openings: []int{4}, NeedsGuess: true},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			l := parseLayout(tc.rows...)
+			stats := Analyze(l, tc.start)
+			if !reflect.DeepEqual(stats, tc.expected) {
+				t.Fatalf("expected: %+v, got: %+v", tc.expected, stats)
+			}
+			if bbbv := BBBV(l); bbbv != tc.expected.BBBV {
+				t.Fatalf("expected: %v, got: %v", tc.expected.BBBV, bbbv)
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+// Package api serves the games over HTTP with JSON bodies, so the bots and the web front-ends can play
+// without linking the engine. Every game has an opaque ID, the moves of one game are applied one at a time
+// and the games not used for the idle timeout are removed
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miner/game"
+	"github.com/miner/logger"
+)
+
+const (
+	defaultIdleTimeout = 30 * time.Minute
+	maxBodySize        = 1 << 10
+	// maxBoardSide limits the board created by the client, the whole board is sent with every answer
+	maxBoardSide = 100
+	// maxNoGuessCells limits the no-guess boards, their generation holds the game lock for up to the generation budget
+	maxNoGuessCells = 30 * 30
+)
+
+var ErrNotFound = errors.New("game not found")
+var ErrBadRequest = errors.New("invalid request body")
+var ErrMethodNotAllowed = errors.New("method not allowed")
+
+type Option func(*Service)
+
+// WithIdleTimeout sets how long the game is kept after its last request. Default is 30 minutes
+func WithIdleTimeout(d time.Duration) Option {
+	return func(s *Service) {
+		if d > 0 {
+			s.idle = d
+		}
+	}
+}
+
+// Service is the HTTP handler of the games:
+//
+//	POST   /games              create the game, the body is NewGame
+//	GET    /games/{id}         the player-visible board
+//	DELETE /games/{id}         remove the game
+//	POST   /games/{id}/reveal  reveal the cell, the body is Move
+//	POST   /games/{id}/chord   reveal the neighbours of the satisfied number
+//	POST   /games/{id}/flag    flag the hidden cell
+//	POST   /games/{id}/unflag  remove the flag
+type Service struct {
+	log  logger.Logger
+	idle time.Duration
+	mux  *http.ServeMux
+	stop chan struct{}
+	once sync.Once
+
+	mu    sync.Mutex
+	games map[string]*entry
+}
+
+// entry is the game with its lock, the lock keeps the moves of concurrent requests apart. used is guarded by Service.mu
+type entry struct {
+	mu   sync.Mutex
+	game game.Game
+	used time.Time
+}
+
+// New creates the service and starts removing the idle games, Close stops it
+func New(log logger.Logger, opts ...Option) *Service {
+	s := &Service{
+		log:   log,
+		idle:  defaultIdleTimeout,
+		mux:   http.NewServeMux(),
+		stop:  make(chan struct{}),
+		games: make(map[string]*entry),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.mux.HandleFunc("/games", s.create)
+	s.mux.HandleFunc("/games/", s.route)
+	go s.expire()
+	return s
+}
+
+func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// ListenAndServe serves the API on the TCP address
+func (s *Service) ListenAndServe(addr string) error {
+	srv := &http.Server{Addr: addr, Handler: s, ReadHeaderTimeout: 10 * time.Second}
+	s.log.Info("api", "listening on %s", addr)
+	return srv.ListenAndServe()
+}
+
+// Close stops removing the idle games
+func (s *Service) Close() {
+	s.once.Do(func() { close(s.stop) })
+}
+
+// expire removes the idle games every half of the idle timeout
+func (s *Service) expire() {
+	t := time.NewTicker(s.idle / 2)
+	defer t.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case now := <-t.C:
+			s.mu.Lock()
+			for id, e := range s.games {
+				if now.Sub(e.used) > s.idle {
+					delete(s.games, id)
+					s.log.Debug("api", "game %s expired", id)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// lookup returns the game and marks it used, the idle game is removed even if the sweep has not reached it yet
+func (s *Service) lookup(id string) (*entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.games[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	now := time.Now()
+	if now.Sub(e.used) > s.idle {
+		delete(s.games, id)
+		return nil, ErrNotFound
+	}
+	e.used = now
+	return e, nil
+}
+
+func (s *Service) create(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, ErrMethodNotAllowed)
+		return
+	}
+	var req NewGame
+	if err := decode(w, r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	if req.Mines <= 0 || req.Width > maxBoardSide || req.Height > maxBoardSide ||
+		req.NoGuess && req.Width*req.Height > maxNoGuessCells {
+		writeError(w, game.ErrInvalidSettings)
+		return
+	}
+	opts := []game.Option{game.WithMines(req.Mines), game.WithFirstClick(game.FirstClickOpening)}
+	if req.Seed != 0 {
+		opts = append(opts, game.WithSeed(req.Seed))
+	}
+	if req.NoGuess {
+		opts = append(opts, game.WithNoGuess())
+	}
+	g := game.NewGame()
+	if err := g.StartRect(req.Width, req.Height, 0, opts...); err != nil {
+		writeError(w, err)
+		return
+	}
+	id, err := newID()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	s.mu.Lock()
+	s.games[id] = &entry{game: g, used: time.Now()}
+	s.mu.Unlock()
+	s.log.Debug("api", "game %s created: %dx%dx%d", id, req.Width, req.Height, req.Mines)
+	writeJSON(w, http.StatusCreated, newBoard(id, g))
+}
+
+// route serves the requests of one game: /games/{id} and /games/{id}/{action}
+func (s *Service) route(w http.ResponseWriter, r *http.Request) {
+	id, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/games/"), "/")
+	e, err := s.lookup(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		writeJSON(w, http.StatusOK, newBoard(id, e.game))
+	case action == "" && r.Method == http.MethodDelete:
+		s.mu.Lock()
+		delete(s.games, id)
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	case action == "":
+		writeError(w, ErrMethodNotAllowed)
+	default:
+		s.move(w, r, id, e, game.Action(action))
+	}
+}
+
+// move applies the move to the locked game and answers with the changed cells and the board
+func (s *Service) move(w http.ResponseWriter, r *http.Request, id string, e *entry, action game.Action) {
+	switch action {
+	case game.ActionReveal, game.ActionChord, game.ActionFlag, game.ActionUnflag:
+	default:
+		writeError(w, ErrNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeError(w, ErrMethodNotAllowed)
+		return
+	}
+	var m Move
+	if err := decode(w, r, &m); err != nil {
+		writeError(w, err)
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	cells, _, err := (game.Step{Action: action, X: m.X, Y: m.Y}).Apply(e.game)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	result := MoveResult{Changed: make([]Cell, 0, len(cells)), Board: newBoard(id, e.game)}
+	for _, cell := range cells {
+		result.Changed = append(result.Changed, newCell(cell))
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// decode reads the small JSON body, unknown fields are rejected to catch the typos of the clients
+func decode(w http.ResponseWriter, r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return ErrBadRequest
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError answers with the error message and the status of its kind
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrMethodNotAllowed):
+		status = http.StatusMethodNotAllowed
+	case errors.Is(err, ErrBadRequest), errors.Is(err, game.ErrInvalidSettings), errors.Is(err, game.ErrInvalidPosition):
+		status = http.StatusBadRequest
+	case errors.Is(err, game.ErrCellFlagged), errors.Is(err, game.ErrCellRevealed),
+		errors.Is(err, game.ErrCellNotRevealed), errors.Is(err, game.ErrGameOver):
+		status = http.StatusConflict
+	case errors.Is(err, game.ErrGenerationBudget):
+		status = http.StatusUnprocessableEntity
+	}
+	writeJSON(w, status, Error{Error: err.Error()})
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.New("id: " + err.Error())
+	}
+	return hex.EncodeToString(b), nil
+}
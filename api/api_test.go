@@ -0,0 +1,203 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miner/game"
+	"github.com/miner/logger"
+)
+
+func newServer(t *testing.T, opts ...Option) *httptest.Server {
+	t.Helper()
+	s := New(logger.NewLog(), opts...)
+	ts := httptest.NewServer(s)
+	t.Cleanup(func() {
+		ts.Close()
+		s.Close()
+	})
+	return ts
+}
+
+// do sends the request with the JSON body and decodes the answer into out, returns the status
+func do(t *testing.T, method, url string, body, out any) int {
+	t.Helper()
+	var b bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&b).Encode(body)
+	}
+	req, err := http.NewRequest(method, url, &b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("%s %s: %v", method, url, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func create(t *testing.T, ts *httptest.Server) Board {
+	t.Helper()
+	var b Board
+	if status := do(t, http.MethodPost, ts.URL+"/games", NewGame{Width: 9, Height: 9, Mines: 10, Seed: 7}, &b); status != http.StatusCreated {
+		t.Fatalf("expected: %d, got: %d", http.StatusCreated, status)
+	}
+	return b
+}
+
+func TestService_Play(t *testing.T) {
+	ts := newServer(t)
+	b := create(t, ts)
+	if b.ID == "" || b.Width != 9 || b.Height != 9 || b.Mines != 10 || b.Seed != 0 || b.State != game.InProgress {
+		t.Fatalf("unexpected board: %+v", b)
+	}
+	url := ts.URL + "/games/" + b.ID
+
+	var m MoveResult
+	if status := do(t, http.MethodPost, url+"/reveal", Move{X: 4, Y: 4}, &m); status != http.StatusOK {
+		t.Fatalf("reveal: expected: %d, got: %d", http.StatusOK, status)
+	}
+	if len(m.Changed) < 9 || m.Board.Cells[4][4].State != "revealed" || m.Board.Moves != 1 {
+		t.Fatalf("expected the opening, got %d cells, %+v", len(m.Changed), m.Board.Cells[4][4])
+	}
+	var hidden *Cell
+	for x := range m.Board.Cells {
+		for y := range m.Board.Cells[x] {
+			if m.Board.Cells[x][y].State == "hidden" {
+				hidden = &m.Board.Cells[x][y]
+			}
+		}
+	}
+	if status := do(t, http.MethodPost, url+"/flag", Move{X: hidden.X, Y: hidden.Y}, &m); status != http.StatusOK {
+		t.Fatalf("flag: expected: %d, got: %d", http.StatusOK, status)
+	}
+	var got Board
+	if status := do(t, http.MethodGet, url, nil, &got); status != http.StatusOK {
+		t.Fatalf("get: expected: %d, got: %d", http.StatusOK, status)
+	}
+	if got.Cells[hidden.X][hidden.Y].State != "flagged" || got.Remaining != 9 {
+		t.Fatalf("expected the flag, got %+v remaining %d", got.Cells[hidden.X][hidden.Y], got.Remaining)
+	}
+	if status := do(t, http.MethodPost, url+"/unflag", Move{X: hidden.X, Y: hidden.Y}, &m); status != http.StatusOK {
+		t.Fatalf("unflag: expected: %d, got: %d", http.StatusOK, status)
+	}
+
+	if status := do(t, http.MethodDelete, url, nil, nil); status != http.StatusNoContent {
+		t.Fatalf("delete: expected: %d, got: %d", http.StatusNoContent, status)
+	}
+	if status := do(t, http.MethodGet, url, nil, &Error{}); status != http.StatusNotFound {
+		t.Fatalf("get deleted: expected: %d, got: %d", http.StatusNotFound, status)
+	}
+}
+
+func TestService_SeedHidden(t *testing.T) {
+	ts := newServer(t)
+	b := create(t, ts)
+	url := ts.URL + "/games/" + b.ID
+	var raw map[string]json.RawMessage
+	if status := do(t, http.MethodGet, url, nil, &raw); status != http.StatusOK {
+		t.Fatalf("get: expected: %d, got: %d", http.StatusOK, status)
+	}
+	if _, ok := raw["seed"]; ok {
+		t.Fatalf("expected no seed during the game, got %s", raw["seed"])
+	}
+
+	m := MoveResult{Board: b}
+	for x := 0; x < b.Width && m.Board.State == game.InProgress; x++ {
+		for y := 0; y < b.Height && m.Board.State == game.InProgress; y++ {
+			do(t, http.MethodPost, url+"/reveal", Move{X: x, Y: y}, &m)
+		}
+	}
+	if m.Board.State == game.InProgress || m.Board.Seed != 7 {
+		t.Fatalf("expected the seed once the game is over, got %d in state %v", m.Board.Seed, m.Board.State)
+	}
+}
+
+func TestService_Errors(t *testing.T) {
+	ts := newServer(t)
+	b := create(t, ts)
+	url := ts.URL + "/games/" + b.ID
+	do(t, http.MethodPost, url+"/reveal", Move{X: 4, Y: 4}, &MoveResult{})
+
+	tests := map[string]struct {
+		method   string
+		path     string
+		body     any
+		expected int
+		message  string
+	}{
+		"no mines":         {method: http.MethodPost, path: "/games", body: NewGame{Width: 9, Height: 9}, expected: http.StatusBadRequest, message: game.ErrInvalidSettings.Error()},
+		"too many mines":   {method: http.MethodPost, path: "/games", body: NewGame{Width: 3, Height: 3, Mines: 9}, expected: http.StatusBadRequest, message: game.ErrInvalidSettings.Error()},
+		"too large":        {method: http.MethodPost, path: "/games", body: NewGame{Width: 200000, Height: 200000, Mines: 10}, expected: http.StatusBadRequest, message: game.ErrInvalidSettings.Error()},
+		"large no guess":   {method: http.MethodPost, path: "/games", body: NewGame{Width: 40, Height: 40, Mines: 300, NoGuess: true}, expected: http.StatusBadRequest, message: game.ErrInvalidSettings.Error()},
+		"unknown field":    {method: http.MethodPost, path: "/games", body: map[string]int{"size": 9}, expected: http.StatusBadRequest, message: ErrBadRequest.Error()},
+		"list":             {method: http.MethodGet, path: "/games", expected: http.StatusMethodNotAllowed, message: ErrMethodNotAllowed.Error()},
+		"unknown game":     {method: http.MethodGet, path: "/games/nope", expected: http.StatusNotFound, message: ErrNotFound.Error()},
+		"unknown action":   {method: http.MethodPost, path: "/games/" + b.ID + "/undo", body: Move{}, expected: http.StatusNotFound, message: ErrNotFound.Error()},
+		"get move":         {method: http.MethodGet, path: "/games/" + b.ID + "/reveal", expected: http.StatusMethodNotAllowed, message: ErrMethodNotAllowed.Error()},
+		"invalid position": {method: http.MethodPost, path: "/games/" + b.ID + "/reveal", body: Move{X: 9}, expected: http.StatusBadRequest, message: game.ErrInvalidPosition.Error()},
+		"revealed":         {method: http.MethodPost, path: "/games/" + b.ID + "/flag", body: Move{X: 4, Y: 4}, expected: http.StatusConflict, message: game.ErrCellRevealed.Error()},
+		"no body":          {method: http.MethodPost, path: "/games/" + b.ID + "/reveal", expected: http.StatusBadRequest, message: ErrBadRequest.Error()},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var e Error
+			if status := do(t, tc.method, ts.URL+tc.path, tc.body, &e); status != tc.expected {
+				t.Fatalf("expected: %d, got: %d", tc.expected, status)
+			}
+			if e.Error != tc.message {
+				t.Fatalf("expected: %q, got: %q", tc.message, e.Error)
+			}
+		})
+	}
+}
+
+func TestService_Expiry(t *testing.T) {
+	ts := newServer(t, WithIdleTimeout(50*time.Millisecond))
+	b := create(t, ts)
+	url := ts.URL + "/games/" + b.ID
+	for i := 0; i < 3; i++ {
+		time.Sleep(30 * time.Millisecond)
+		if status := do(t, http.MethodGet, url, nil, &Board{}); status != http.StatusOK {
+			t.Fatalf("used game: expected: %d, got: %d", http.StatusOK, status)
+		}
+	}
+	time.Sleep(100 * time.Millisecond)
+	if status := do(t, http.MethodGet, url, nil, &Error{}); status != http.StatusNotFound {
+		t.Fatalf("idle game: expected: %d, got: %d", http.StatusNotFound, status)
+	}
+}
+
+func TestService_Concurrent(t *testing.T) {
+	ts := newServer(t)
+	b := create(t, ts)
+	url := ts.URL + "/games/" + b.ID
+	var wg sync.WaitGroup
+	for x := 0; x < b.Width; x++ {
+		wg.Add(1)
+		go func(x int) {
+			defer wg.Done()
+			for y := 0; y < b.Height; y++ {
+				do(t, http.MethodPost, url+"/flag", Move{X: x, Y: y}, &Error{})
+			}
+		}(x)
+	}
+	wg.Wait()
+	var got Board
+	do(t, http.MethodGet, url, nil, &got)
+	if got.Moves != b.Width*b.Height || got.Remaining != b.Mines-b.Width*b.Height {
+		t.Fatalf("expected every flag applied once, got %d moves, %d remaining", got.Moves, got.Remaining)
+	}
+}
@@ -0,0 +1,86 @@
+package api
+
+import "github.com/miner/game"
+
+// NewGame is the body of the create request. Zero seed is random, NoGuess generates the board solvable without guessing.
+// The sides are limited to 100 cells, the no-guess board to 900 cells
+type NewGame struct {
+	Width   int   `json:"width"`
+	Height  int   `json:"height"`
+	Mines   int   `json:"mines"`
+	Seed    int64 `json:"seed,omitempty"`
+	NoGuess bool  `json:"no_guess,omitempty"`
+}
+
+// Move is the body of the move request
+type Move struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// MoveResult is the answer to the move: the cells changed by it and the board after it
+type MoveResult struct {
+	Changed []Cell `json:"changed"`
+	Board   Board  `json:"board"`
+}
+
+// Board is the player-visible game. Cells are indexed by x then y, the mines and the seed are shown once the game is over
+type Board struct {
+	ID        string         `json:"id"`
+	Width     int            `json:"width"`
+	Height    int            `json:"height"`
+	Mines     int            `json:"mines"`
+	Remaining int            `json:"remaining"`
+	State     game.GameState `json:"state"`
+	Seed      int64          `json:"seed,omitempty"`
+	ElapsedMs int64          `json:"elapsed_ms"`
+	Moves     int            `json:"moves"`
+	Cells     [][]Cell       `json:"cells"`
+}
+
+// Cell is the state of the cell: hidden, revealed, flagged, question, exploded, mine or "wrong flag". Count is set for the revealed cells
+type Cell struct {
+	X     int    `json:"x"`
+	Y     int    `json:"y"`
+	State string `json:"state"`
+	Count int    `json:"count,omitempty"`
+}
+
+// Error is the body of the failed request
+type Error struct {
+	Error string `json:"error"`
+}
+
+func newBoard(id string, g game.Game) Board {
+	s := g.Snapshot()
+	b := Board{
+		ID:        id,
+		Width:     s.Width,
+		Height:    s.Height,
+		Mines:     g.Mines(),
+		Remaining: s.Remaining,
+		State:     s.State,
+		ElapsedMs: s.Elapsed.Milliseconds(),
+		Moves:     s.Moves,
+		Cells:     make([][]Cell, len(s.Cells)),
+	}
+	for x, column := range s.Cells {
+		b.Cells[x] = make([]Cell, len(column))
+		for y, cell := range column {
+			b.Cells[x][y] = newCell(cell)
+		}
+	}
+	// the seed regenerates the layout, it would reveal every mine of the running game
+	if s.State != game.InProgress {
+		b.Seed = g.Seed()
+	}
+	return b
+}
+
+func newCell(c game.Cell) Cell {
+	cell := Cell{X: c.X(), Y: c.Y(), State: c.State().String()}
+	if c.State() == game.Revealed {
+		cell.Count = c.Count()
+	}
+	return cell
+}
@@ -0,0 +1,69 @@
+// Package bot plays the game with the solver, seeing only what the player sees
+package bot
+
+import (
+	"github.com/miner/game"
+	"github.com/miner/solver"
+)
+
+// View converts the player-visible board of the game to the solver board
+func View(board [][]game.Cell) solver.Board {
+	height := 0
+	if len(board) > 0 {
+		height = len(board[0])
+	}
+	b := solver.NewBoard(len(board), height)
+	for x, column := range board {
+		for y, cell := range column {
+			switch cell.State() {
+			case game.Revealed:
+				b.Cells[x*height+y] = cell.Count()
+			case game.Flagged:
+				b.Cells[x*height+y] = solver.Flagged
+			}
+		}
+	}
+	return b
+}
+
+// Play drives the game to its end from the visible board only: it reveals the safe cells, flags the mines
+// and reveals the safest cell when it has to guess. Returns the final game state and the number of guesses
+func Play(g game.Game) (game.GameState, int, error) {
+	guesses := 0
+	for g.State() == game.InProgress {
+		r := solver.Solve(View(g.Board()), g.Mines())
+		for _, cell := range r.Mines {
+			if g.Flagged(cell.X, cell.Y) {
+				continue
+			}
+			if err := g.Flag(cell.X, cell.Y); err != nil {
+				return g.State(), guesses, err
+			}
+		}
+		if len(r.Safe) == 0 {
+			cell, ok := r.Guess()
+			if !ok {
+				// only flags are left, every cell is either revealed or a mine
+				break
+			}
+			guesses++
+			r.Safe = []solver.Cell{cell}
+		}
+		for _, cell := range r.Safe {
+			if g.State() != game.InProgress {
+				break
+			}
+			c, err := g.Cell(cell.X, cell.Y)
+			if err != nil {
+				return g.State(), guesses, err
+			}
+			if c.State() == game.Revealed {
+				continue
+			}
+			if _, _, err := g.Reveal(cell.X, cell.Y); err != nil {
+				return g.State(), guesses, err
+			}
+		}
+	}
+	return g.State(), guesses, nil
+}
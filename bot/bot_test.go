@@ -0,0 +1,105 @@
+package bot
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/miner/game"
+	"github.com/miner/solver"
+)
+
+// TestSolve_Sound checks every deduction against the real layout along the games played by the solver
+func TestSolve_Sound(t *testing.T) {
+	for seed := int64(1); seed <= 30; seed++ {
+		g := game.NewGame()
+		if err := g.StartRect(16, 16, 0, game.WithMines(40), game.WithSeed(seed), game.WithFirstClick(game.FirstClickOpening), game.WithDebug()); err != nil {
+			t.Fatalf("expected: %v, got: %v", nil, err)
+		}
+		g.Reveal(8, 8)
+		layout, err := g.Layout()
+		if err != nil {
+			t.Fatalf("expected: %v, got: %v", nil, err)
+		}
+		for g.State() == game.InProgress {
+			r := solver.Solve(View(g.Board()), g.Mines())
+			for _, cell := range r.Safe {
+				if layout.Mines[cell.X*layout.Height+cell.Y] {
+					t.Fatalf("seed %d: %v is not safe", seed, cell)
+				}
+			}
+			for _, cell := range r.Mines {
+				if !layout.Mines[cell.X*layout.Height+cell.Y] {
+					t.Fatalf("seed %d: %v is not a mine", seed, cell)
+				}
+				if !g.Flagged(cell.X, cell.Y) {
+					g.Flag(cell.X, cell.Y)
+				}
+			}
+			if len(r.Safe) == 0 {
+				break
+			}
+			for _, cell := range r.Safe {
+				if c, _ := g.Cell(cell.X, cell.Y); c.State() != game.Revealed && g.State() == game.InProgress {
+					g.Reveal(cell.X, cell.Y)
+				}
+			}
+		}
+	}
+}
+
+func TestPlay(t *testing.T) {
+	wins := 0
+	for seed := int64(1); seed <= 40; seed++ {
+		g := game.NewGame()
+		if err := g.StartRect(9, 9, 0, game.WithMines(10), game.WithSeed(seed), game.WithFirstClick(game.FirstClickOpening)); err != nil {
+			t.Fatalf("expected: %v, got: %v", nil, err)
+		}
+		state, guesses, err := Play(g)
+		if err != nil {
+			t.Fatalf("expected: %v, got: %v", nil, err)
+		}
+		if state == game.InProgress {
+			t.Fatalf("seed %d: expected the game to end", seed)
+		}
+		if state == game.Lose && guesses < 2 {
+			t.Fatalf("seed %d: lost without guessing after the first click", seed)
+		}
+		if state == game.Win {
+			wins++
+		}
+	}
+	if wins < 32 {
+		t.Fatalf("expected at least %v wins of 40, got: %v", 32, wins)
+	}
+}
+
+// TestPlay_Concurrent plays the seeds in parallel, run with -race. Every game must end as it does when played alone
+func TestPlay_Concurrent(t *testing.T) {
+	play := func(seed int64) game.GameState {
+		g := game.NewGame()
+		if err := g.StartRect(9, 9, 0, game.WithMines(10), game.WithSeed(seed), game.WithFirstClick(game.FirstClickOpening)); err != nil {
+			t.Errorf("expected: %v, got: %v", nil, err)
+			return ""
+		}
+		state, _, err := Play(g)
+		if err != nil {
+			t.Errorf("expected: %v, got: %v", nil, err)
+		}
+		return state
+	}
+	states := make([]game.GameState, 16)
+	var wg sync.WaitGroup
+	for i := range states {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			states[i] = play(int64(i + 1))
+		}(i)
+	}
+	wg.Wait()
+	for i, state := range states {
+		if alone := play(int64(i + 1)); state != alone {
+			t.Fatalf("seed %d: expected: %v, got: %v", i+1, alone, state)
+		}
+	}
+}
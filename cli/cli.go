@@ -0,0 +1,457 @@
+// Package cli is the command line of the miner binary
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/miner/analysis"
+	"github.com/miner/api"
+	"github.com/miner/bot"
+	"github.com/miner/config"
+	"github.com/miner/game"
+	"github.com/miner/internal/sysuser"
+	"github.com/miner/logger"
+	"github.com/miner/scores"
+	"github.com/miner/server"
+	"github.com/miner/tui"
+	"github.com/miner/ui"
+)
+
+const (
+	defaultWidth  = 16
+	defaultHeight = 16
+	defaultMines  = 40
+)
+
+var ErrUnknownCommand = errors.New("unknown command")
+var ErrRemoteTerminal = errors.New("the terminal client cannot join the server, play in the window")
+var ErrUnknownPreset = errors.New("unknown preset")
+
+type command struct {
+	name  string
+	usage string
+	run   func(c *CLI, args []string) error
+}
+
+var commands = []command{
+	{name: "play", usage: "play in the window or with -tui in the terminal, board flags or -size skip the settings scene, -connect joins the server", run: (*CLI).play},
+	{name: "solve", usage: "let the solver play the board, with -games play several seeds and print the win rate", run: (*CLI).solve},
+	{name: "generate", usage: "print the bomb layout of the board and its difficulty", run: (*CLI).generate},
+	{name: "replay", usage: "print the result of the replay file, with -gui play it back in the window", run: (*CLI).replay},
+	{name: "scores", usage: "print the high-score table", run: (*CLI).scores},
+	{name: "serve", usage: "host the multiplayer rooms, coop on one board or race on the same layout", run: (*CLI).serve},
+	{name: "api", usage: "serve the HTTP/JSON API of the games for the bots and the web front-ends", run: (*CLI).api},
+}
+
+type CLI struct {
+	log logger.Logger
+	out io.Writer
+}
+
+func New(log logger.Logger, out io.Writer) *CLI {
+	return &CLI{log: log, out: out}
+}
+
+// Run runs the subcommand named by the first argument. Without a subcommand the game is played
+func (c *CLI) Run(args []string) error {
+	name := "play"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" {
+		c.usage()
+		return nil
+	}
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd.run(c, args)
+		}
+	}
+	c.usage()
+	return fmt.Errorf("%w: %s", ErrUnknownCommand, name)
+}
+
+func (c *CLI) usage() {
+	fmt.Fprintln(c.out, "usage: miner [command] [flags]")
+	for _, cmd := range commands {
+		fmt.Fprintf(c.out, "  %-10s %s\n", cmd.name, cmd.usage)
+	}
+}
+
+// board is the board settings shared by the subcommands
+type board struct {
+	width  int
+	height int
+	mines  int
+	seed   int64
+}
+
+// common is the flags of every subcommand
+type common struct {
+	level  string
+	config string
+}
+
+// newFlagSet creates the flags of the subcommand with the common flags, the log level is applied by parse
+func (c *CLI) newFlagSet(name string) (*flag.FlagSet, *common) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.out)
+	cm := &common{}
+	fs.StringVar(&cm.level, "log-level", "info", "minimal log level: debug, info, warn, error")
+	fs.StringVar(&cm.config, "config", "", "config file, default is $MINER_CONFIG or config.json in the user config directory")
+	return fs, cm
+}
+
+func boardFlags(fs *flag.FlagSet) *board {
+	b := &board{}
+	fs.IntVar(&b.width, "width", defaultWidth, "board width")
+	fs.IntVar(&b.height, "height", defaultHeight, "board height")
+	fs.IntVar(&b.mines, "mines", defaultMines, "number of mines")
+	fs.Int64Var(&b.seed, "seed", 0, "seed of the bomb layout, 0 is random")
+	return b
+}
+
+func parse(fs *flag.FlagSet, cm *common, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	return logger.SetLevel(cm.level)
+}
+
+// loadConfig reads the config file, the problems are logged and the defaults are used instead of the invalid values
+func (c *CLI) loadConfig(cm *common) config.Config {
+	path := cm.config
+	if path == "" {
+		var err error
+		path, err = config.DefaultPath()
+		if err != nil {
+			c.log.Warn("config", "DefaultPath: %v", err)
+		}
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		c.log.Warn("config", "Load: %v", err)
+	}
+	return cfg
+}
+
+// presetBoard sets the board from the size and difficulty presets of the config, the board flags given explicitly are kept.
+// Without the size the board flags are used as they are
+func presetBoard(cfg config.Config, size, difficulty string, fs *flag.FlagSet, b *board) error {
+	if size == "" {
+		return nil
+	}
+	s, ok := cfg.Sizes[size]
+	if !ok {
+		return fmt.Errorf("%w: size %q", ErrUnknownPreset, size)
+	}
+	percent, ok := cfg.Difficulties[difficulty]
+	if !ok {
+		return fmt.Errorf("%w: difficulty %q", ErrUnknownPreset, difficulty)
+	}
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if !set["width"] {
+		b.width = s.Width
+	}
+	if !set["height"] {
+		b.height = s.Height
+	}
+	if !set["mines"] {
+		b.mines = b.width * b.height * percent / 100
+	}
+	return nil
+}
+
+// boardSet reports whether any of the board flags was given
+func boardSet(fs *flag.FlagSet) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "width", "height", "mines", "seed":
+			set = true
+		}
+	})
+	return set
+}
+
+func (c *CLI) play(args []string) error {
+	fs, cm := c.newFlagSet("play")
+	terminal := fs.Bool("tui", false, "play in the terminal instead of the window")
+	noGuess := fs.Bool("no-guess", false, "generate boards solvable without guessing")
+	connect := fs.String("connect", "", "address of the server to play on, the board flags create the room")
+	room := fs.String("room", "default", "room to join on the server")
+	name := fs.String("player", "", "player name in the room, default is the user name")
+	mode := fs.String("mode", string(server.ModeCoop), "mode of the new room: coop or race")
+	size := fs.String("size", "", "board size preset of the config, the board flags override it")
+	difficulty := fs.String("difficulty", "normal", "difficulty preset of the config used with -size")
+	b := boardFlags(fs)
+	if err := parse(fs, cm, args); err != nil {
+		return err
+	}
+	cfg := c.loadConfig(cm)
+	if err := presetBoard(cfg, *size, *difficulty, fs, b); err != nil {
+		return err
+	}
+	if *connect != "" {
+		if *terminal {
+			return ErrRemoteTerminal
+		}
+		if *name == "" {
+			*name = sysuser.Name()
+		}
+		r, err := server.Dial(*connect, server.Join{Room: *room, Player: *name, Mode: server.Mode(*mode),
+			Width: b.width, Height: b.height, Mines: b.mines, Seed: b.seed})
+		if err != nil {
+			return err
+		}
+		return ui.NewClient(c.log, cfg).RunRemote(r)
+	}
+	if *terminal {
+		return tui.NewClient(c.log, tui.Settings{Width: b.width, Height: b.height, Mines: b.mines, Seed: b.seed, NoGuess: *noGuess, Keys: cfg.Keys}).Run()
+	}
+	client := ui.NewClient(c.log, cfg)
+	if boardSet(fs) || *noGuess || *size != "" {
+		return client.RunBoard(ui.Board{Width: b.width, Height: b.height, Mines: b.mines, Seed: b.seed, NoGuess: *noGuess})
+	}
+	return client.Run()
+}
+
+func (c *CLI) solve(args []string) error {
+	fs, cm := c.newFlagSet("solve")
+	b := boardFlags(fs)
+	games := fs.Int("games", 1, "number of games, the seed of every next game is one more")
+	noGuess := fs.Bool("no-guess", false, "generate boards solvable without guessing")
+	if err := parse(fs, cm, args); err != nil {
+		return err
+	}
+	if *games < 1 {
+		return errors.New("solve: -games must be positive")
+	}
+	seed := b.seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	wins := 0
+	for i := 0; i < *games; i++ {
+		g := game.NewGame()
+		opts := []game.Option{game.WithMines(b.mines), game.WithSeed(seed + int64(i)), game.WithFirstClick(game.FirstClickOpening)}
+		if *noGuess {
+			opts = append(opts, game.WithNoGuess())
+		}
+		if err := g.StartRect(b.width, b.height, 0, opts...); err != nil {
+			return err
+		}
+		state, guesses, err := bot.Play(g)
+		if err != nil {
+			return err
+		}
+		if state == game.Win {
+			wins++
+		}
+		if *games == 1 {
+			fmt.Fprintf(c.out, "board: %dx%dx%d seed: %d\n", b.width, b.height, g.Mines(), g.Seed())
+			fmt.Fprintf(c.out, "state: %v guesses: %d moves: %d\n", state, guesses, g.Moves())
+			return writeBoard(c.out, g.Board())
+		}
+	}
+	fmt.Fprintf(c.out, "board: %dx%dx%d seeds: %d..%d\n", b.width, b.height, b.mines, seed, seed+int64(*games)-1)
+	fmt.Fprintf(c.out, "wins: %d/%d (%.1f%%)\n", wins, *games, 100*float64(wins)/float64(*games))
+	return nil
+}
+
+func (c *CLI) generate(args []string) error {
+	fs, cm := c.newFlagSet("generate")
+	b := boardFlags(fs)
+	if err := parse(fs, cm, args); err != nil {
+		return err
+	}
+	opts := []game.Option{game.WithMines(b.mines), game.WithDebug()}
+	if b.seed != 0 {
+		opts = append(opts, game.WithSeed(b.seed))
+	}
+	g := game.NewGame()
+	if err := g.StartRect(b.width, b.height, 0, opts...); err != nil {
+		return err
+	}
+	layout, err := g.Layout()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.out, "board: %dx%dx%d seed: %d\n", layout.Width, layout.Height, g.Mines(), g.Seed())
+	writeStats(c.out, g.Stats())
+	return writeLayout(c.out, layout)
+}
+
+func (c *CLI) replay(args []string) error {
+	fs, cm := c.newFlagSet("replay")
+	gui := fs.Bool("gui", false, "play the replay back in the window")
+	if err := parse(fs, cm, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("replay: expected the replay file")
+	}
+	path := fs.Arg(0)
+	if *gui {
+		return ui.NewClient(c.log, c.loadConfig(cm)).RunReplay(path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r, err := game.ReadReplay(f)
+	if err != nil {
+		return err
+	}
+	// the clock follows the step times, so the elapsed time is the recorded one
+	var at time.Duration
+	base := time.Now()
+	g := game.NewGame()
+	if err := r.Start(g, game.WithClock(func() time.Time { return base.Add(at) })); err != nil {
+		return err
+	}
+	for i, s := range r.Steps {
+		at = s.At
+		if _, _, err := s.Apply(g); err != nil {
+			return fmt.Errorf("step %d: %w", i, err)
+		}
+	}
+	fmt.Fprintf(c.out, "board: %dx%dx%d seed: %d\n", r.Width, r.Height, g.Mines(), r.Seed)
+	fmt.Fprintf(c.out, "state: %v moves: %d time: %.2fs\n", g.State(), len(r.Steps), g.Elapsed().Seconds())
+	writeStats(c.out, g.Stats())
+	return writeBoard(c.out, g.Board())
+}
+
+func (c *CLI) serve(args []string) error {
+	fs, cm := c.newFlagSet("serve")
+	addr := fs.String("addr", ":7777", "TCP address to listen on")
+	reconnect := fs.Duration("reconnect", time.Minute, "how long the disconnected player keeps the place in the room")
+	if err := parse(fs, cm, args); err != nil {
+		return err
+	}
+	return server.New(c.log, server.WithReconnectTimeout(*reconnect)).ListenAndServe(*addr)
+}
+
+func (c *CLI) api(args []string) error {
+	fs, cm := c.newFlagSet("api")
+	addr := fs.String("addr", ":8080", "HTTP address to listen on")
+	idle := fs.Duration("idle", 30*time.Minute, "how long the game is kept after its last request")
+	if err := parse(fs, cm, args); err != nil {
+		return err
+	}
+	s := api.New(c.log, api.WithIdleTimeout(*idle))
+	defer s.Close()
+	return s.ListenAndServe(*addr)
+}
+
+func (c *CLI) scores(args []string) error {
+	fs, cm := c.newFlagSet("scores")
+	b := boardFlags(fs)
+	if err := parse(fs, cm, args); err != nil {
+		return err
+	}
+	path, err := scores.DefaultPath()
+	if err != nil {
+		return err
+	}
+	store := scores.NewStore(path)
+	boards := []scores.Board{{Width: b.width, Height: b.height, Mines: b.mines}}
+	if !boardSet(fs) {
+		boards, err = store.Boards()
+		if err != nil {
+			return err
+		}
+	}
+	for _, board := range boards {
+		entries, err := store.Top(board)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(c.out, board)
+		for i, e := range entries {
+			fmt.Fprintf(c.out, "%2d. %-12s %7.2fs  3bv %-4d %s seed %d\n", i+1, e.Name, e.Time.Seconds(), e.BBBV, e.Date.Format("2006-01-02"), e.Seed)
+		}
+	}
+	return nil
+}
+
+// writeStats prints the difficulty of the board, the openings and islands are listed by size
+func writeStats(w io.Writer, s analysis.Stats) {
+	guess := "no"
+	if s.NeedsGuess {
+		guess = "yes"
+	}
+	fmt.Fprintf(w, "3bv: %d openings: %d %v islands: %d %v needs guess: %s\n", s.BBBV, len(s.Openings), s.Openings, len(s.Islands), s.Islands, guess)
+}
+
+// writeLayout prints the solved board: bombs as *, empty cells as . and the bomb counts
+func writeLayout(w io.Writer, l analysis.Layout) error {
+	var sb strings.Builder
+	for y := 0; y < l.Height; y++ {
+		for x := 0; x < l.Width; x++ {
+			if l.Mines[x*l.Height+y] {
+				sb.WriteByte('*')
+				continue
+			}
+			count := 0
+			for dx := -1; dx <= 1; dx++ {
+				for dy := -1; dy <= 1; dy++ {
+					nx, ny := x+dx, y+dy
+					if nx >= 0 && nx < l.Width && ny >= 0 && ny < l.Height && l.Mines[nx*l.Height+ny] {
+						count++
+					}
+				}
+			}
+			sb.WriteByte(countChar(count))
+		}
+		sb.WriteByte('\n')
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// writeBoard prints the player-visible board, one character per cell
+func writeBoard(w io.Writer, cells [][]game.Cell) error {
+	var sb strings.Builder
+	for y := 0; len(cells) > 0 && y < len(cells[0]); y++ {
+		for x := range cells {
+			sb.WriteByte(cellChar(cells[x][y]))
+		}
+		sb.WriteByte('\n')
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func cellChar(cell game.Cell) byte {
+	switch cell.State() {
+	case game.Revealed:
+		return countChar(cell.Count())
+	case game.Flagged:
+		return 'F'
+	case game.Question:
+		return '?'
+	case game.Mine:
+		return '*'
+	case game.Exploded:
+		return 'X'
+	case game.WrongFlag:
+		return 'x'
+	}
+	return '#'
+}
+
+func countChar(count int) byte {
+	if count == 0 {
+		return '.'
+	}
+	return byte('0' + count)
+}
@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/miner/config"
+	"github.com/miner/game"
+)
+
+func TestCLI_Generate(t *testing.T) {
+	run := func() string {
+		var out bytes.Buffer
+		if err := New(nil, &out).Run([]string{"generate", "-width", "8", "-height", "5", "-mines", "6", "-seed", "3"}); err != nil {
+			t.Fatalf("expected: %v, got: %v", nil, err)
+		}
+		return out.String()
+	}
+	first := run()
+	if first != run() {
+		t.Fatalf("expected the same layout for the same seed")
+	}
+	lines := strings.Split(strings.TrimSpace(first), "\n")
+	if lines[0] != "board: 8x5x6 seed: 3" || !strings.HasPrefix(lines[1], "3bv: ") || len(lines) != 7 {
+		t.Fatalf("unexpected output: %q", first)
+	}
+	if bombs := strings.Count(first, "*"); bombs != 6 {
+		t.Fatalf("expected: %v, got: %v", 6, bombs)
+	}
+}
+
+func TestCLI_Replay(t *testing.T) {
+	g := game.NewGame()
+	if err := g.StartRect(5, 5, 0, game.WithMines(3), game.WithSeed(9), game.WithFirstClick(game.FirstClickOpening), game.WithDebug()); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	g.Reveal(2, 2)
+	g.Flag(0, 0)
+	path := filepath.Join(t.TempDir(), "game.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if err := g.Replay().Write(f); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	f.Close()
+
+	var out bytes.Buffer
+	if err := New(nil, &out).Run([]string{"replay", path}); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	var board bytes.Buffer
+	writeBoard(&board, g.Board())
+	if !strings.Contains(out.String(), "moves: 2") || !strings.HasSuffix(out.String(), board.String()) {
+		t.Fatalf("unexpected output: %q", out.String())
+	}
+}
+
+func TestCLI_UnknownCommand(t *testing.T) {
+	var out bytes.Buffer
+	err := New(nil, &out).Run([]string{"fly"})
+	if !errors.Is(err, ErrUnknownCommand) {
+		t.Fatalf("expected: %v, got: %v", ErrUnknownCommand, err)
+	}
+	if !strings.Contains(out.String(), "usage") {
+		t.Fatalf("expected the usage, got: %q", out.String())
+	}
+}
+
+func TestCLI_Solve(t *testing.T) {
+	var out bytes.Buffer
+	if err := New(nil, &out).Run([]string{"solve", "-width", "9", "-height", "9", "-mines", "10", "-seed", "1", "-games", "10"}); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if !strings.Contains(out.String(), "seeds: 1..10") || !strings.Contains(out.String(), "/10 (") {
+		t.Fatalf("unexpected output: %q", out.String())
+	}
+}
+
+func TestCLI_SolveNoGuess(t *testing.T) {
+	var out bytes.Buffer
+	if err := New(nil, &out).Run([]string{"solve", "-width", "16", "-height", "16", "-mines", "40", "-seed", "1", "-games", "5", "-no-guess"}); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if !strings.Contains(out.String(), "wins: 5/5 (") {
+		t.Fatalf("unexpected output: %q", out.String())
+	}
+}
+
+func TestCLI_PresetBoard(t *testing.T) {
+	cfg := config.Default()
+	cfg.Sizes["huge"] = config.Size{Width: 50, Height: 40, CellSize: 10}
+	tests := map[string]struct {
+		size, difficulty string
+		args             []string
+		expected         board
+		expectedErr      error
+	}{
+		"no preset":          {args: []string{"-width", "9"}, expected: board{width: 9, height: defaultHeight, mines: defaultMines}},
+		"configured preset":  {size: "huge", difficulty: "hard", expected: board{width: 50, height: 40, mines: 600}},
+		"flags override":     {size: "expert", difficulty: "easy", args: []string{"-height", "10", "-mines", "5"}, expected: board{width: 30, height: 10, mines: 5}},
+		"unknown size":       {size: "giant", difficulty: "easy", expectedErr: ErrUnknownPreset},
+		"unknown difficulty": {size: "small", difficulty: "insane", expectedErr: ErrUnknownPreset},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			fs := flag.NewFlagSet("play", flag.ContinueOnError)
+			b := boardFlags(fs)
+			if err := fs.Parse(tc.args); err != nil {
+				t.Fatalf("expected: %v, got: %v", nil, err)
+			}
+			err := presetBoard(cfg, tc.size, tc.difficulty, fs, b)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected: %v, got: %v", tc.expectedErr, err)
+			}
+			if err == nil && *b != tc.expected {
+				t.Fatalf("expected: %+v, got: %+v", tc.expected, *b)
+			}
+		})
+	}
+}
@@ -0,0 +1,334 @@
+// Package config loads the board presets, window size, colours and keys of the game from the JSON file and the environment
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	fileName      = "config.json"
+	envPrefix     = "MINER_"
+	minWindowW    = 640
+	minWindowH    = 480
+	maxWindowSide = 4096
+	maxBoardSide  = 100
+	minCellSize   = 10
+	maxCellSize   = 60
+)
+
+var ErrInvalidConfig = errors.New("invalid config")
+
+// Window is the size of the game window in pixels, it cannot be smaller than the settings scene
+type Window struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// Size is the board preset of the settings scene
+type Size struct {
+	Width    int `json:"width"`
+	Height   int `json:"height"`
+	CellSize int `json:"cell_size"`
+}
+
+// Config is the full set of tunables. Every value is valid, the invalid ones are replaced with the defaults
+type Config struct {
+	Window       Window
+	Sizes        map[string]Size
+	Difficulties map[string]int
+	Colors       map[string]color.RGBA
+	Keys         map[string]string
+}
+
+// file is the on-disk format, colours are written as #rrggbb or #rrggbbaa
+type file struct {
+	Window       Window            `json:"window"`
+	Sizes        map[string]Size   `json:"sizes"`
+	Difficulties map[string]int    `json:"difficulties"`
+	Colors       map[string]string `json:"colors"`
+	Keys         map[string]string `json:"keys"`
+}
+
+// Default returns the built-in configuration
+func Default() Config {
+	return Config{
+		Window: Window{Width: 640, Height: 480},
+		Sizes: map[string]Size{
+			"small":  {Width: 10, Height: 10, CellSize: 30},
+			"medium": {Width: 14, Height: 14, CellSize: 25},
+			"large":  {Width: 20, Height: 20, CellSize: 20},
+			"expert": {Width: 30, Height: 16, CellSize: 20},
+		},
+		Difficulties: map[string]int{
+			"easy":   10,
+			"normal": 20,
+			"hard":   30,
+		},
+		Colors: map[string]color.RGBA{
+			"green":    {178, 222, 39, 1},
+			"yellow":   {249, 215, 28, 1},
+			"red":      {236, 100, 75, 1},
+			"dark_red": {150, 30, 20, 255},
+			"grey":     {128, 128, 128, 128},
+			"cyan":     {20, 205, 200, 1},
+			"black":    {0, 0, 0, 0},
+			"cell":     {100, 255, 255, 255},
+			"font":     {255, 255, 255, 1},
+		},
+		Keys: map[string]string{
+			"undo":  "Z",
+			"redo":  "Y",
+			"pause": "Spacebar",
+			"step":  "RightArrow",
+			"hint":  "H",
+		},
+	}
+}
+
+// DefaultPath returns the config file path, MINER_CONFIG overrides the one in the user config directory
+func DefaultPath() (string, error) {
+	if path, ok := os.LookupEnv(envPrefix + "CONFIG"); ok && path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "miner", fileName), nil
+}
+
+// Load reads the config file and applies the MINER_* environment overrides on top of it. A missing file is not an error.
+// The returned config is always usable: on error it holds the defaults for the invalid values and the error lists them
+func Load(path string) (Config, error) {
+	return load(path, os.Environ())
+}
+
+func load(path string, environ []string) (Config, error) {
+	cfg := Default()
+	var problems []string
+	if path != "" {
+		f, err := readFile(path)
+		if err != nil {
+			problems = append(problems, err.Error())
+		} else {
+			problems = append(problems, cfg.merge(f)...)
+		}
+	}
+	problems = append(problems, cfg.mergeEnv(environ)...)
+	if len(problems) > 0 {
+		return cfg, fmt.Errorf("%w: %s", ErrInvalidConfig, strings.Join(problems, "; "))
+	}
+	return cfg, nil
+}
+
+func readFile(path string) (file, error) {
+	var f file
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return f, err
+	}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return file{}, fmt.Errorf("%s: %v", path, err)
+	}
+	return f, nil
+}
+
+// merge applies the values set in the file, zero values keep the defaults. Returns the problems of the skipped values
+func (c *Config) merge(f file) []string {
+	var problems []string
+	add := func(problem string) {
+		if problem != "" {
+			problems = append(problems, problem)
+		}
+	}
+	add(c.setWindow(f.Window))
+	for _, name := range sortedKeys(f.Sizes) {
+		add(c.setSize(name, f.Sizes[name]))
+	}
+	for _, name := range sortedKeys(f.Difficulties) {
+		add(c.setDifficulty(name, f.Difficulties[name]))
+	}
+	for _, name := range sortedKeys(f.Colors) {
+		add(c.setColor(name, f.Colors[name]))
+	}
+	for _, name := range sortedKeys(f.Keys) {
+		add(c.setKey(name, f.Keys[name]))
+	}
+	return problems
+}
+
+// mergeEnv applies the overrides: MINER_WINDOW=WxH, MINER_SIZE_<NAME>=WxH or WxHxCELL, MINER_DIFFICULTY_<NAME>=PERCENT,
+// MINER_COLOR_<NAME>=#rrggbb and MINER_KEY_<NAME>=KEY
+func (c *Config) mergeEnv(environ []string) []string {
+	var problems []string
+	add := func(problem string) {
+		if problem != "" {
+			problems = append(problems, problem)
+		}
+	}
+	for _, kv := range environ {
+		name, value, _ := strings.Cut(kv, "=")
+		switch {
+		case name == envPrefix+"WINDOW":
+			values, err := dimensionsOf(value, 2)
+			if err != nil {
+				add(fmt.Sprintf("%s: %v", name, err))
+				continue
+			}
+			add(c.setWindow(Window{Width: values[0], Height: values[1]}))
+		case strings.HasPrefix(name, envPrefix+"SIZE_"):
+			size := strings.ToLower(strings.TrimPrefix(name, envPrefix+"SIZE_"))
+			values, err := dimensionsOf(value, 3)
+			if err != nil {
+				add(fmt.Sprintf("%s: %v", name, err))
+				continue
+			}
+			add(c.setSize(size, Size{Width: values[0], Height: values[1], CellSize: values[2]}))
+		case strings.HasPrefix(name, envPrefix+"DIFFICULTY_"):
+			percent, err := strconv.Atoi(value)
+			if err != nil {
+				add(fmt.Sprintf("%s: %v", name, err))
+				continue
+			}
+			add(c.setDifficulty(strings.ToLower(strings.TrimPrefix(name, envPrefix+"DIFFICULTY_")), percent))
+		case strings.HasPrefix(name, envPrefix+"COLOR_"):
+			add(c.setColor(strings.ToLower(strings.TrimPrefix(name, envPrefix+"COLOR_")), value))
+		case strings.HasPrefix(name, envPrefix+"KEY_"):
+			add(c.setKey(strings.ToLower(strings.TrimPrefix(name, envPrefix+"KEY_")), value))
+		}
+	}
+	return problems
+}
+
+func (c *Config) setWindow(w Window) string {
+	if w.Width == 0 && w.Height == 0 {
+		return ""
+	}
+	if w.Width == 0 {
+		w.Width = c.Window.Width
+	}
+	if w.Height == 0 {
+		w.Height = c.Window.Height
+	}
+	if w.Width < minWindowW || w.Height < minWindowH || w.Width > maxWindowSide || w.Height > maxWindowSide {
+		return fmt.Sprintf("window %dx%d must be from %dx%d to %dx%d", w.Width, w.Height, minWindowW, minWindowH, maxWindowSide, maxWindowSide)
+	}
+	c.Window = w
+	return ""
+}
+
+// setSize changes the preset or adds the new one, the new preset sets the width, the height and the cell size
+func (c *Config) setSize(name string, s Size) string {
+	if name == "" {
+		return "size without a name"
+	}
+	current, ok := c.Sizes[name]
+	if !ok && (s.Width == 0 || s.Height == 0 || s.CellSize == 0) {
+		return fmt.Sprintf("new size %q needs the width, the height and the cell size", name)
+	}
+	if s.Width == 0 {
+		s.Width = current.Width
+	}
+	if s.Height == 0 {
+		s.Height = current.Height
+	}
+	if s.CellSize == 0 {
+		s.CellSize = current.CellSize
+	}
+	if s.Width < 1 || s.Height < 1 || s.Width > maxBoardSide || s.Height > maxBoardSide {
+		return fmt.Sprintf("size %q: board %dx%d must be from 1x1 to %dx%d", name, s.Width, s.Height, maxBoardSide, maxBoardSide)
+	}
+	if s.CellSize < minCellSize || s.CellSize > maxCellSize {
+		return fmt.Sprintf("size %q: cell size %d must be from %d to %d", name, s.CellSize, minCellSize, maxCellSize)
+	}
+	c.Sizes[name] = s
+	return ""
+}
+
+// setDifficulty changes the percent of the mines of the difficulty or adds the new one
+func (c *Config) setDifficulty(name string, percent int) string {
+	if name == "" {
+		return "difficulty without a name"
+	}
+	if percent < 1 || percent > 99 {
+		return fmt.Sprintf("difficulty %q: %d%% must be from 1 to 99", name, percent)
+	}
+	c.Difficulties[name] = percent
+	return ""
+}
+
+func (c *Config) setColor(name, value string) string {
+	if _, ok := c.Colors[name]; !ok {
+		return fmt.Sprintf("unknown color %q", name)
+	}
+	rgba, err := parseColor(value)
+	if err != nil {
+		return fmt.Sprintf("color %q: %v", name, err)
+	}
+	c.Colors[name] = rgba
+	return ""
+}
+
+func (c *Config) setKey(name, value string) string {
+	if _, ok := c.Keys[name]; !ok {
+		return fmt.Sprintf("unknown key binding %q", name)
+	}
+	if value == "" {
+		return fmt.Sprintf("key binding %q is empty", name)
+	}
+	c.Keys[name] = value
+	return ""
+}
+
+// parseColor parses #rrggbb with the opaque alpha or #rrggbbaa
+func parseColor(s string) (color.RGBA, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	if len(hex) != 8 {
+		return color.RGBA{}, fmt.Errorf("%q is not #rrggbb or #rrggbbaa", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("%q is not #rrggbb or #rrggbbaa", s)
+	}
+	return color.RGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
+
+// dimensionsOf parses WxH or, if max allows, WxHxC. Missing values are zero
+func dimensionsOf(s string, max int) ([]int, error) {
+	parts := strings.Split(s, "x")
+	if len(parts) < 2 || len(parts) > max {
+		return nil, fmt.Errorf("%q is not WxH", s)
+	}
+	values := make([]int, max)
+	for i, p := range parts {
+		v, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not WxH", s)
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"errors"
+	"image/color"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeConfig(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), fileName)
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	return path
+}
+
+func TestLoad_Missing(t *testing.T) {
+	cfg, err := load(filepath.Join(t.TempDir(), fileName), nil)
+	if err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if !reflect.DeepEqual(cfg, Default()) {
+		t.Fatalf("expected the defaults, got: %+v", cfg)
+	}
+}
+
+func TestLoad_File(t *testing.T) {
+	path := writeConfig(t, `{
+		"window": {"width": 800},
+		"sizes": {"small": {"width": 8, "height": 9}, "expert": {"cell_size": 22}, "huge": {"width": 50, "height": 40, "cell_size": 10}},
+		"difficulties": {"hard": 25, "insane": 40},
+		"colors": {"cell": "#102030", "grey": "#40506070"},
+		"keys": {"undo": "U"}
+	}`)
+	cfg, err := load(path, nil)
+	if err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	expected := Default()
+	expected.Window = Window{Width: 800, Height: 480}
+	expected.Sizes["small"] = Size{Width: 8, Height: 9, CellSize: 30}
+	expected.Sizes["expert"] = Size{Width: 30, Height: 16, CellSize: 22}
+	expected.Sizes["huge"] = Size{Width: 50, Height: 40, CellSize: 10}
+	expected.Difficulties["hard"] = 25
+	expected.Difficulties["insane"] = 40
+	expected.Colors["cell"] = color.RGBA{0x10, 0x20, 0x30, 0xff}
+	expected.Colors["grey"] = color.RGBA{0x40, 0x50, 0x60, 0x70}
+	expected.Keys["undo"] = "U"
+	if !reflect.DeepEqual(cfg, expected) {
+		t.Fatalf("expected: %+v, got: %+v", expected, cfg)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"not json":         `{`,
+		"small window":     `{"window": {"width": 320, "height": 200}}`,
+		"incomplete size":  `{"sizes": {"huge": {"width": 50, "height": 50}}}`,
+		"new difficulty":   `{"difficulties": {"insane": 120}}`,
+		"big board":        `{"sizes": {"large": {"width": 500}}}`,
+		"small cells":      `{"sizes": {"large": {"cell_size": 2}}}`,
+		"difficulty range": `{"difficulties": {"easy": 100}}`,
+		"bad color":        `{"colors": {"red": "red"}}`,
+		"unknown color":    `{"colors": {"pink": "#ff00ff"}}`,
+		"unknown key":      `{"keys": {"jump": "J"}}`,
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := load(writeConfig(t, data), nil)
+			if !errors.Is(err, ErrInvalidConfig) {
+				t.Fatalf("expected: %v, got: %v", ErrInvalidConfig, err)
+			}
+			if !reflect.DeepEqual(cfg, Default()) {
+				t.Fatalf("expected the defaults, got: %+v", cfg)
+			}
+		})
+	}
+}
+
+func TestLoad_Env(t *testing.T) {
+	path := writeConfig(t, `{"sizes": {"small": {"width": 8, "height": 8}}, "difficulties": {"easy": 12}}`)
+	environ := []string{
+		"MINER_WINDOW=1024x768",
+		"MINER_SIZE_SMALL=12x11",
+		"MINER_SIZE_MEDIUM=15x15x24",
+		"MINER_SIZE_TINY=5x5x30",
+		"MINER_SIZE_=5x5x30",
+		"MINER_DIFFICULTY_EASY=15",
+		"MINER_COLOR_FONT=#000000",
+		"MINER_KEY_REDO=R",
+		"MINER_DIFFICULTY_HARD=lots",
+		"HOME=/root",
+	}
+	cfg, err := load(path, environ)
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("expected: %v, got: %v", ErrInvalidConfig, err)
+	}
+	expected := Default()
+	expected.Window = Window{Width: 1024, Height: 768}
+	expected.Sizes["small"] = Size{Width: 12, Height: 11, CellSize: 30}
+	expected.Sizes["medium"] = Size{Width: 15, Height: 15, CellSize: 24}
+	expected.Sizes["tiny"] = Size{Width: 5, Height: 5, CellSize: 30}
+	expected.Difficulties["easy"] = 15
+	expected.Colors["font"] = color.RGBA{0, 0, 0, 0xff}
+	expected.Keys["redo"] = "R"
+	if !reflect.DeepEqual(cfg, expected) {
+		t.Fatalf("expected: %+v, got: %+v", expected, cfg)
+	}
+}
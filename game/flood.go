@@ -0,0 +1,57 @@
+package game
+
+// flood is the scratch space of the flood fill: the bitset of the collected cells and the cells in the fill order,
+// which is also the queue of the fill. Both are sized for the whole board and kept between the moves
+type flood struct {
+	seen  []uint64
+	cells []int
+}
+
+func (f *flood) add(i int) bool {
+	word, bit := i/64, uint64(1)<<(i%64)
+	if f.seen[word]&bit != 0 {
+		return false
+	}
+	f.seen[word] |= bit
+	f.cells = append(f.cells, i)
+	return true
+}
+
+// take returns the collected cells and clears the bitset for the next move. The cells are valid until the next fill
+func (f *flood) take() []int {
+	cells := f.cells
+	for _, i := range cells {
+		f.seen[i/64] = 0
+	}
+	f.cells = f.cells[:0]
+	return cells
+}
+
+// check collects the cell to reveal and, if it has no bombs around, floods the empty region with its numbered border.
+// Flagged cells stop the flood. The fill is iterative, the collected cells are the queue
+func (g *Miner) check(x, y int) {
+	f := &g.flood
+	cells := g.width * g.height
+	if words := (cells + 63) / 64; len(f.seen) != words || cap(f.cells) < cells {
+		// the fill collects at most every cell of the board, so the queue sized for the board never grows.
+		// Boards of the same word count differ in cells, the queue of the smaller one is replaced
+		f.seen, f.cells = make([]uint64, words), make([]int, 0, cells)
+	}
+	next := len(f.cells)
+	if !f.add(g.grid.index(x, y)) {
+		return
+	}
+	var buf [8]int
+	for ; next < len(f.cells); next++ {
+		p := g.grid.position(f.cells[next])
+		if g.grid.cells[p.x][p.y].count > 0 {
+			continue
+		}
+		for _, n := range g.grid.nearCells(p.x, p.y, buf[:0]) {
+			q := g.grid.position(n)
+			if !g.grid.isFlagged(q.x, q.y) {
+				f.add(n)
+			}
+		}
+	}
+}
@@ -3,109 +3,603 @@ package game
 import (
 	"errors"
 	"math/rand"
+	"sync"
 	"time"
+
+	"github.com/miner/analysis"
 )
 
 var ErrInvalidPosition = errors.New("invalid x y position")
-var ErrInvalidSettings = errors.New("invalid size or difficulty")
+var ErrInvalidSettings = errors.New("invalid size, difficulty or mines")
+var ErrCellFlagged = errors.New("cell is flagged")
+var ErrCellRevealed = errors.New("cell is already revealed")
+var ErrCellNotRevealed = errors.New("cell is not revealed")
+var ErrGameOver = errors.New("game is over")
+var ErrDebugDisabled = errors.New("game is not started with the debug option")
 
+// Miner is the game engine. Its methods are safe for concurrent use, every call sees and leaves the whole move applied.
+// The bomb layout is hidden: Layout needs WithDebug, Seed and the seed of Replay are held back until the game is over
+// unless it is started with WithDebug. Save writes the layout, the save file restores the running game
+// and is kept by the front-end of the player, it is never sent to the other clients.
+// The board size is read with Snapshot, the difficulty with Difficulty
 type Miner struct {
-	Size          int
-	Difficulty    int
-	BombsCount    int
-	RevealedCount int
-	Bombs         map[int]Position
-	Grid          *Grid
+	mu         sync.Mutex
+	width      int
+	height     int
+	difficulty int
+	bombsCount int
+	bombs      map[int]Position
+	grid       *Grid
+	options    options
+	placed     bool
+	rand       *rand.Rand
+	state      GameState
+	counters   Counters
+	started    time.Time
+	ended      time.Time
+	action     *action
+	undo       []*action
+	redo       []*action
+	assisted   bool
+	opened     time.Time
+	steps      []Step
+	flood      flood
 }
 
 func NewGame() *Miner {
 	return &Miner{
-		Bombs: make(map[int]Position),
+		bombs: make(map[int]Position),
 	}
 }
 
 func (g *Miner) cells() []Cell {
-	all := make([]Cell, 0, g.Size*g.Size)
-	for _, cells := range g.Grid.cells {
+	all := make([]Cell, 0, g.width*g.height)
+	for _, cells := range g.grid.cells {
 		all = append(all, cells...)
 	}
 	return all
 }
 
 // Reveal checks the given cell with incoming coordinates
-// If bomb - returns all cells for revealing, game state - lose.
+// If bomb - the cell is exploded, returns all cells for revealing, game state - lose.
+// If cell is flagged, returns ErrCellFlagged.
+// If cell is already revealed, nothing changes: the click is neither counted nor recorded.
 // If cell is empty, recursively collects all adjacent empty cells to reveal, game state is in progress. Recursive cell traversal ends if cell bomb count is greater than 0
 // If all possible cells are revealed, the game state is win, returns all cells to be revealed.
 func (g *Miner) Reveal(x, y int) ([]Cell, GameState, error) {
-	if !g.Grid.validatedPosition(x, y) {
-		return nil, InProgress, ErrInvalidPosition
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.grid.validatedPosition(x, y) {
+		return nil, g.state, ErrInvalidPosition
+	}
+	if g.state != InProgress {
+		return nil, g.state, ErrGameOver
+	}
+	if g.grid.isFlagged(x, y) {
+		return nil, InProgress, ErrCellFlagged
+	}
+	if g.grid.isRevealed(x, y) {
+		return []Cell{}, InProgress, nil
+	}
+	var bombs map[int]Position
+	if !g.placed {
+		var err error
+		if bombs, err = g.placeBombsAround(x, y); err != nil {
+			return nil, g.state, err
+		}
+	}
+	g.record(ActionReveal, x, y)
+	g.begin()
+	defer g.commit()
+	if !g.placed {
+		g.place(bombs)
+	}
+	if g.started.IsZero() {
+		g.started = g.options.now()
+	}
+	g.counters.LeftClicks++
+	if g.grid.getCell(x, y).HasBomb() {
+		return g.lose(Position{x, y}), Lose, nil
+	}
+	g.check(x, y)
+	cells, state := g.reveal(g.flood.take())
+	return cells, state, nil
+}
+
+// Chord reveals all unflagged neighbours of the revealed numbered cell if the number of flagged neighbours equals its bomb count.
+// If the count is not satisfied yet or no neighbour is left to reveal, nothing changes: the chord is neither counted nor recorded.
+// If one of the flags was wrong, returns all cells for revealing, game state - lose.
+func (g *Miner) Chord(x, y int) ([]Cell, GameState, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.grid.validatedPosition(x, y) {
+		return nil, g.state, ErrInvalidPosition
+	}
+	if g.state != InProgress {
+		return nil, g.state, ErrGameOver
+	}
+	if !g.grid.isRevealed(x, y) {
+		return nil, InProgress, ErrCellNotRevealed
+	}
+	cell := g.grid.getCell(x, y)
+	var buf [8]int
+	var hiddenBuf [8]Position
+	hidden := hiddenBuf[:0]
+	flags := 0
+	for _, position := range g.grid.nearCells(x, y, buf[:0]) {
+		p := g.grid.position(position)
+		switch {
+		case g.grid.isFlagged(p.x, p.y):
+			flags++
+		case !g.grid.isRevealed(p.x, p.y):
+			hidden = append(hidden, p)
+		}
+	}
+	if cell.count == 0 || flags != cell.count || len(hidden) == 0 {
+		return []Cell{}, InProgress, nil
+	}
+	g.record(ActionChord, x, y)
+	g.counters.Chords++
+	g.begin()
+	defer g.commit()
+	var exploded []Position
+	for _, p := range hidden {
+		if g.grid.getCell(p.x, p.y).HasBomb() {
+			exploded = append(exploded, p)
+		}
+	}
+	if len(exploded) > 0 {
+		return g.lose(exploded...), Lose, nil
+	}
+	for _, p := range hidden {
+		g.check(p.x, p.y)
+	}
+	cells, state := g.reveal(g.flood.take())
+	return cells, state, nil
+}
+
+// reveal marks the collected cells as revealed and checks the win condition.
+// On win all unflagged bombs are flagged and returned together with the revealed cells.
+// The newly revealed cells are compacted in place, so the returned cells are allocated once at their final size
+func (g *Miner) reveal(revealed []int) ([]Cell, GameState) {
+	if a := g.action; a != nil && a.revealed == nil {
+		a.revealed = make([]int32, 0, len(revealed))
+	}
+	n := 0
+	for _, i := range revealed {
+		p := g.grid.position(i)
+		if g.grid.isRevealed(p.x, p.y) {
+			continue
+		}
+		g.set(p, Revealed)
+		revealed[n] = i
+		n++
+	}
+	revealed = revealed[:n]
+	win := g.width*g.height-g.grid.revealedCount == g.bombsCount
+	size := len(revealed)
+	if win {
+		size += len(g.bombs)
 	}
-	revealedCells := make([]Cell, 0)
-	if g.Grid.getCell(x, y).HasBomb() {
-		return g.cells(), Lose, nil
+	revealedCells := make([]Cell, 0, size)
+	for _, i := range revealed {
+		p := g.grid.position(i)
+		revealedCells = append(revealedCells, g.grid.getCell(p.x, p.y))
 	}
-	revealed := make(map[int]Position)
-	g.check(x, y, revealed)
-	for k, p := range revealed {
-		revealedCells = append(revealedCells, g.Grid.getCell(p.x, p.y))
-		g.Grid.revealed[k] = p
+	if win {
+		for _, p := range g.bombs {
+			if g.grid.isFlagged(p.x, p.y) {
+				continue
+			}
+			g.set(p, Flagged)
+			revealedCells = append(revealedCells, g.grid.getCell(p.x, p.y))
+		}
+		g.end(Win)
+		return revealedCells, Win
+	}
+	return revealedCells, InProgress
+}
+
+// lose marks the exploded bombs, shows all other unflagged bombs and wrong flags. Returns all cells
+func (g *Miner) lose(exploded ...Position) []Cell {
+	for x, column := range g.grid.cells {
+		for y, cell := range column {
+			switch {
+			case cell.bomb && cell.state != Flagged:
+				g.set(Position{x, y}, Mine)
+			case !cell.bomb && cell.state == Flagged:
+				g.set(Position{x, y}, WrongFlag)
+			}
+		}
 	}
-	if g.Size*g.Size-len(g.Grid.revealed) == g.BombsCount {
-		return revealedCells, Win, nil
+	for _, p := range exploded {
+		g.set(p, Exploded)
 	}
-	return revealedCells, InProgress, nil
+	g.end(Lose)
+	return g.cells()
+}
+
+// Start initiate the game on the square board with the given settings. See StartRect
+func (g *Miner) Start(size, difficulty int, opts ...Option) error {
+	return g.StartRect(size, size, difficulty, opts...)
 }
 
-// Start initiate the game with the given settings. Cannot be created if the settings are null. Cell matrix with uniform distribution is created
-func (g *Miner) Start(size, difficulty int) error {
-	if size <= 0 || difficulty <= 0 {
+// StartRect initiate the game on the width x height board with the given settings. Cannot be created if the settings are null. Cell matrix with uniform distribution is created.
+// The number of bombs is the difficulty percentage of cells, unless the exact number is set with WithMines, then difficulty is ignored.
+// Bombs are placed right away, unless the first click safety or the no-guess option is set, then they are placed on the first reveal
+func (g *Miner) StartRect(width, height, difficulty int, opts ...Option) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	o := newOptions(opts)
+	if width <= 0 || height <= 0 {
 		return ErrInvalidSettings
 	}
-	g.Size, g.Difficulty = size, difficulty
-	g.BombsCount = (g.Size * g.Size * g.Difficulty) / 100
-	rand.Seed(time.Now().UnixNano())
-	for i := 0; i < g.BombsCount; i++ {
-		b := rand.Intn(g.Size * g.Size)
-		if _, ok := g.Bombs[b]; !ok {
-			g.Bombs[b] = Position{b / g.Size, b % g.Size}
+	bombs := o.mines
+	if bombs != 0 {
+		if bombs < 0 || bombs >= width*height {
+			return ErrInvalidSettings
+		}
+		difficulty = bombs * 100 / (width * height)
+	} else {
+		if difficulty <= 0 || difficulty > 100 {
+			return ErrInvalidSettings
+		}
+		bombs = (width * height * difficulty) / 100
+	}
+	if (o.firstClick != FirstClickAny || o.noGuess) && bombs >= width*height {
+		return ErrInvalidSettings
+	}
+	g.options = o
+	g.width, g.height, g.difficulty = width, height, difficulty
+	g.bombsCount = bombs
+	if !g.options.seeded {
+		g.options.seed = time.Now().UnixNano()
+	}
+	g.rand = rand.New(rand.NewSource(g.options.seed))
+	g.bombs = make(map[int]Position)
+	g.placed = false
+	g.state = InProgress
+	g.counters = Counters{}
+	g.undo, g.redo = nil, nil
+	g.assisted = g.options.practice
+	g.started, g.ended = time.Time{}, time.Time{}
+	g.opened, g.steps = g.options.now(), nil
+	if g.options.firstClick == FirstClickAny && !g.options.noGuess {
+		g.bombs, g.placed = g.placeBombs(nil), true
+	}
+	g.grid = newGrid(g.width, g.height, g.bombs)
+	return nil
+}
+
+// end finishes the game with the given state and stops the timer
+func (g *Miner) end(state GameState) {
+	g.state = state
+	g.ended = g.options.now()
+	if g.started.IsZero() {
+		g.started = g.ended
+	}
+}
+
+// State returns the current game state
+func (g *Miner) State() GameState {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.state
+}
+
+// Moves returns the number of accepted player actions: reveals, chords and marks
+func (g *Miner) Moves() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.counters.Total()
+}
+
+// Counters returns the number of accepted player actions by kind
+func (g *Miner) Counters() Counters {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.counters
+}
+
+// StartedAt returns the time of the first reveal, zero if the game is not started yet
+func (g *Miner) StartedAt() time.Time {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.started
+}
+
+// EndedAt returns the time the game was won or lost, zero if the game is in progress
+func (g *Miner) EndedAt() time.Time {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.ended
+}
+
+// Elapsed returns the time since the first reveal, the timer stops when the game is over
+func (g *Miner) Elapsed() time.Duration {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.elapsed()
+}
+
+func (g *Miner) elapsed() time.Duration {
+	if g.started.IsZero() {
+		return 0
+	}
+	if !g.ended.IsZero() {
+		return g.ended.Sub(g.started)
+	}
+	return g.options.now().Sub(g.started)
+}
+
+// Board returns the player-visible view of every cell, indexed by x then y. Bombs of hidden cells are not exposed
+func (g *Miner) Board() [][]Cell {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.board()
+}
+
+func (g *Miner) board() [][]Cell {
+	board := make([][]Cell, g.width)
+	for x := range board {
+		board[x] = make([]Cell, g.height)
+		for y := range board[x] {
+			board[x][y] = g.grid.getCell(x, y).visible()
+		}
+	}
+	return board
+}
+
+// Snapshot returns the player-visible board together with the game progress
+func (g *Miner) Snapshot() Snapshot {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return Snapshot{
+		Width:     g.width,
+		Height:    g.height,
+		Cells:     g.board(),
+		State:     g.state,
+		Remaining: g.remaining(),
+		Elapsed:   g.elapsed(),
+		Moves:     g.counters.Total(),
+		Counters:  g.counters,
+	}
+}
+
+// Seed returns the seed the bomb layout was generated with. It regenerates the layout, so it is zero while the game is played,
+// unless the game is started with WithDebug
+func (g *Miner) Seed() int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.disclosed() {
+		return 0
+	}
+	return g.options.seed
+}
+
+// disclosed reports whether the layout can be read: the game is over or started with WithDebug
+func (g *Miner) disclosed() bool {
+	return g.state != InProgress || g.options.debug
+}
+
+// placeBombs distributes bombs uniformly over all cells except the excluded ones
+func (g *Miner) placeBombs(excluded map[int]Position) map[int]Position {
+	bombs := make(map[int]Position, g.bombsCount)
+	for i := 0; i < g.bombsCount; i++ {
+		b := g.rand.Intn(g.width * g.height)
+		if _, ok := excluded[b]; ok {
+			i--
+			continue
+		}
+		if _, ok := bombs[b]; !ok {
+			bombs[b] = Position{b / g.height, b % g.height}
 		} else {
 			i--
 		}
 	}
+	return bombs
+}
+
+// placeBombsAround returns the bomb layout keeping the first revealed cell free according to the first click mode.
+// In the no-guess mode the neighbours are always kept free and the layout is solvable by logic from the cell
+func (g *Miner) placeBombsAround(x, y int) (map[int]Position, error) {
+	excluded := map[int]Position{g.grid.index(x, y): {x, y}}
+	if g.options.firstClick == FirstClickOpening || g.options.noGuess {
+		var buf [8]int
+		near := g.grid.nearCells(x, y, buf[:0])
+		if g.width*g.height-len(near)-1 >= g.bombsCount {
+			for _, position := range near {
+				excluded[position] = g.grid.position(position)
+			}
+		}
+	}
+	if g.options.noGuess {
+		return g.placeNoGuessBombs(g.grid.index(x, y), excluded)
+	}
+	return g.placeBombs(excluded), nil
+}
+
+// place replaces the bomb layout before the first reveal. Flags placed before are kept
+func (g *Miner) place(bombs map[int]Position) {
+	// the bomb map is replaced, not filled, so the undo history keeps the empty one
+	g.bombs, g.placed = bombs, true
+	grid := newGrid(g.width, g.height, g.bombs)
+	for x := range grid.cells {
+		for y := range grid.cells[x] {
+			grid.cells[x][y].state = g.grid.cells[x][y].state
+		}
+	}
+	grid.flaggedCount = g.grid.flaggedCount
+	g.grid = grid
+}
+
+// Flag marks the hidden cell as a suspected bomb. Flagged cells cannot be revealed until unflagged
+func (g *Miner) Flag(x, y int) error {
+	return g.mark(x, y, Flagged, ActionFlag)
+}
+
+// Question marks the hidden cell as uncertain. Unlike flagged cells, question cells can be revealed
+func (g *Miner) Question(x, y int) error {
+	return g.mark(x, y, Question, ActionQuestion)
+}
+
+// Unflag removes the flag or the question mark from the cell. Unflagging a cell without a mark does nothing
+func (g *Miner) Unflag(x, y int) error {
+	return g.mark(x, y, Hidden, ActionUnflag)
+}
+
+// mark sets the player mark of the hidden cell
+func (g *Miner) mark(x, y int, state CellState, action Action) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.grid.validatedPosition(x, y) {
+		return ErrInvalidPosition
+	}
+	if g.state != InProgress {
+		return ErrGameOver
+	}
+	cell := g.grid.getCell(x, y)
+	if cell.state != Hidden && cell.state != Flagged && cell.state != Question {
+		return ErrCellRevealed
+	}
+	g.record(action, x, y)
+	g.begin()
+	defer g.commit()
+	g.set(Position{x, y}, state)
+	g.counters.RightClicks++
+	return nil
+}
+
+// Cell returns the player-visible view of the cell: bomb and count are known only for revealed cells
+func (g *Miner) Cell(x, y int) (Cell, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.grid.validatedPosition(x, y) {
+		return Cell{}, ErrInvalidPosition
+	}
+	return g.grid.getCell(x, y).visible(), nil
+}
+
+// Flagged reports whether the cell is flagged
+func (g *Miner) Flagged(x, y int) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.grid.validatedPosition(x, y) && g.grid.isFlagged(x, y)
+}
+
+// Remaining returns the number of bombs minus the number of placed flags. Can be negative if the player placed too many flags
+func (g *Miner) Remaining() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.remaining()
+}
+
+func (g *Miner) remaining() int {
+	return g.bombsCount - g.grid.flaggedCount
+}
+
+// Difficulty returns the percent of the cells that are mines, computed from the count set with WithMines
+func (g *Miner) Difficulty() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.difficulty
+}
+
+// Mines returns the number of bombs on the board
+func (g *Miner) Mines() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.bombsCount
+}
+
+// Revealed returns the number of the revealed cells
+func (g *Miner) Revealed() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.grid == nil {
+		return 0
+	}
+	return g.grid.revealedCount
+}
+
+// Layout returns the bomb layout, the mines are known before they are found. Only for the games started with WithDebug,
+// others return ErrDebugDisabled. The layout is empty until bombs are placed
+func (g *Miner) Layout() (analysis.Layout, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.options.debug {
+		return analysis.Layout{}, ErrDebugDisabled
+	}
+	return g.layout(g.bombs), nil
+}
+
+// BBBV returns the 3BV of the board: the minimum number of clicks needed to clear it without flags.
+// Every opening counts once and every numbered cell not bordering an opening counts once. Zero until bombs are placed
+func (g *Miner) BBBV() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.placed {
+		return 0
+	}
+	return analysis.BBBV(g.layout(g.bombs))
+}
+
+// Stats returns the difficulty metrics of the board, the guesses are checked from the first reveal. Zero until bombs are placed
+func (g *Miner) Stats() analysis.Stats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.placed {
+		return analysis.Stats{}
+	}
+	start := -1
+	for _, s := range g.steps {
+		if s.Action == ActionReveal {
+			start = g.grid.index(s.X, s.Y)
+			break
+		}
+	}
+	return analysis.Analyze(g.layout(g.bombs), start)
+}
+
+// layout returns the bombs as the analysed layout
+func (g *Miner) layout(bombs map[int]Position) analysis.Layout {
+	l := analysis.Layout{Width: g.width, Height: g.height, Mines: make([]bool, g.width*g.height)}
+	for i := range bombs {
+		l.Mines[i] = true
+	}
+	return l
+}
+
+func newGrid(width, height int, bombs map[int]Position) *Grid {
 	grid := &Grid{
-		cells:    make([][]Cell, g.Size),
-		revealed: make(map[int]Position),
+		width:  width,
+		height: height,
+		cells:  make([][]Cell, width),
 	}
 	for x := range grid.cells {
-		grid.cells[x] = make([]Cell, g.Size)
+		grid.cells[x] = make([]Cell, height)
 		for y := range grid.cells[x] {
 			bomb := false
-			if _, ok := g.Bombs[x*g.Size+y]; ok {
+			if _, ok := bombs[grid.index(x, y)]; ok {
 				bomb = true
 			}
 			grid.cells[x][y] = Cell{
 				Position: Position{x, y},
-				revealed: false,
+				state:    Hidden,
 				bomb:     bomb,
 				count:    0,
 			}
 
 		}
 	}
-	for x := range grid.cells {
-		for y := range grid.cells[x] {
-			count := 0
-			cellNumbers := grid.nearCells(x, y)
-			for _, cellNumber := range cellNumbers {
-				if grid.cells[cellNumber/g.Size][cellNumber%g.Size].HasBomb() {
-					count++
-				}
-			}
-			grid.cells[x][y].count = count
+	var buf [8]int
+	for _, b := range bombs {
+		for _, n := range grid.nearCells(b.x, b.y, buf[:0]) {
+			p := grid.position(n)
+			grid.cells[p.x][p.y].count++
 		}
 	}
-	g.Grid = grid
-	return nil
+	return grid
 }
 
 type Position struct {
@@ -113,19 +607,69 @@ type Position struct {
 }
 
 type Grid struct {
-	cells    [][]Cell
-	revealed map[int]Position
+	width         int
+	height        int
+	cells         [][]Cell
+	revealedCount int
+	flaggedCount  int
 }
 
 func (g *Grid) getCell(x, y int) Cell {
 	return g.cells[x][y]
 }
 
+// index returns the cell number of the x y position, cells are numbered column by column
+func (g *Grid) index(x, y int) int {
+	return x*g.height + y
+}
+
+// position returns the x y position of the cell number
+func (g *Grid) position(i int) Position {
+	return Position{i / g.height, i % g.height}
+}
+
+// count keeps the number of revealed and flagged cells when the cell with the given state is added or removed
+func (g *Grid) count(state CellState, delta int) {
+	switch state {
+	case Revealed:
+		g.revealedCount += delta
+	case Flagged:
+		g.flaggedCount += delta
+	}
+}
+
+func (g *Grid) isRevealed(x, y int) bool {
+	return g.cells[x][y].state == Revealed
+}
+
+func (g *Grid) isFlagged(x, y int) bool {
+	return g.cells[x][y].state == Flagged
+}
+
 type Cell struct {
 	Position
-	revealed bool
-	bomb     bool
-	count    int
+	state CellState
+	bomb  bool
+	count int
+}
+
+// NewCell returns the player-visible cell, for the boards played elsewhere such as on the server
+func NewCell(x, y int, state CellState, count int) Cell {
+	return Cell{Position: Position{x, y}, state: state, count: count}.visible()
+}
+
+// State returns the state of the cell
+func (c Cell) State() CellState {
+	return c.state
+}
+
+// visible hides the bomb and the count of the cell until they are shown to the player
+func (c Cell) visible() Cell {
+	switch c.state {
+	case Revealed, Mine, Exploded, WrongFlag:
+		return c
+	}
+	return Cell{Position: c.Position, state: c.state}
 }
 
 func (c Cell) X() int {
@@ -143,65 +687,24 @@ func (g *Grid) validatedPosition(x, y int) bool {
 	if x < 0 || y < 0 {
 		return false
 	}
-	if x >= len(g.cells) || y >= len(g.cells) {
+	if x >= g.width || y >= g.height {
 		return false
 	}
 	return true
 
 }
 
-func (g *Grid) nearCells(x, y int) []int {
-	var i, j int
-	size := len(g.cells)
-	cellNumbers := make([]int, 0)
-	i, j = x-1, y-1
-	if g.validatedPosition(i, j) {
-		cellNumbers = append(cellNumbers, i*size+j)
-	}
-	i, j = x-1, y
-	if g.validatedPosition(i, j) {
-		cellNumbers = append(cellNumbers, i*size+j)
-	}
-	i, j = x-1, y+1
-	if g.validatedPosition(i, j) {
-		cellNumbers = append(cellNumbers, i*size+j)
-	}
-	i, j = x, y-1
-	if g.validatedPosition(i, j) {
-		cellNumbers = append(cellNumbers, i*size+j)
-	}
-	i, j = x, y+1
-	if g.validatedPosition(i, j) {
-		cellNumbers = append(cellNumbers, i*size+j)
-	}
-	i, j = x+1, y-1
-	if g.validatedPosition(i, j) {
-		cellNumbers = append(cellNumbers, i*size+j)
-	}
-	i, j = x+1, y
-	if g.validatedPosition(i, j) {
-		cellNumbers = append(cellNumbers, i*size+j)
-	}
-	i, j = x+1, y+1
-	if g.validatedPosition(i, j) {
-		cellNumbers = append(cellNumbers, i*size+j)
-	}
-	return cellNumbers
-}
-
-func (g *Miner) check(x, y int, revealed map[int]Position) {
-	cell := g.Grid.getCell(x, y)
-	revealed[x*g.Size+y] = Position{x, y}
-	if cell.count > 0 {
-		return
-	}
-
-	for _, position := range g.Grid.nearCells(x, y) {
-		if _, ok := revealed[position]; ok {
-			continue
+// nearCells appends the numbers of the cells around the x y position to buf and returns it.
+// With the buffer of capacity 8 nothing is allocated
+func (g *Grid) nearCells(x, y int, buf []int) []int {
+	for i := x - 1; i <= x+1; i++ {
+		for j := y - 1; j <= y+1; j++ {
+			if (i != x || j != y) && g.validatedPosition(i, j) {
+				buf = append(buf, g.index(i, j))
+			}
 		}
-		g.check(position/g.Size, position%g.Size, revealed)
 	}
+	return buf
 }
 
 func (c Cell) HasBomb() bool {
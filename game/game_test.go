@@ -1,27 +1,38 @@
 package game
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
+	"io"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/miner/analysis"
 )
 
 func TestMiner_Reveal(t *testing.T) {
 	tests := map[string]struct {
 		size          int
 		difficulty    int
+		seed          int64
 		x, y          int
 		expectedErr   error
 		expectedState GameState
 	}{
-		"win check":  {size: 10, difficulty: 1, x: 0, y: 0, expectedErr: nil, expectedState: Win},
-		"lose check": {size: 10, difficulty: 100, x: 0, y: 0, expectedErr: nil, expectedState: Lose},
-		"wrong x y":  {size: 10, difficulty: 1, x: -1, y: 0, expectedErr: ErrInvalidPosition, expectedState: InProgress},
+		"win check":  {size: 10, difficulty: 1, seed: 4, x: 0, y: 0, expectedErr: nil, expectedState: Win},
+		"lose check": {size: 10, difficulty: 100, seed: 4, x: 0, y: 0, expectedErr: nil, expectedState: Lose},
+		"wrong x y":  {size: 10, difficulty: 1, seed: 4, x: -1, y: 0, expectedErr: ErrInvalidPosition, expectedState: InProgress},
 	}
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
 			game := NewGame()
-			game.Start(tc.size, tc.difficulty)
+			game.Start(tc.size, tc.difficulty, WithSeed(tc.seed))
 			_, actualState, err := game.Reveal(tc.x, tc.y)
 			if err != nil && tc.expectedErr != nil {
 				if !errors.Is(err, tc.expectedErr) {
@@ -44,6 +55,7 @@ func TestMiner_Start(t *testing.T) {
 		"correct settings":          {size: 10, difficulty: 10, expectedErr: nil},
 		"wrong settings difficulty": {size: 10, difficulty: 0, expectedErr: ErrInvalidSettings},
 		"wrong settings size":       {size: 0, difficulty: 10, expectedErr: ErrInvalidSettings},
+		"wrong settings too hard":   {size: 10, difficulty: 101, expectedErr: ErrInvalidSettings},
 	}
 
 	for name, tc := range tests {
@@ -78,3 +90,1357 @@ func TestCellGetters(t *testing.T) {
 		t.Fatalf("expected: %v, got: %v", count, c.Count())
 	}
 }
+
+func newTestMiner(size int, bombs ...Position) *Miner {
+	return newTestRectMiner(size, size, bombs...)
+}
+
+func newTestRectMiner(width, height int, bombs ...Position) *Miner {
+	g := NewGame()
+	g.options = newOptions(nil)
+	g.width, g.height = width, height
+	g.bombsCount = len(bombs)
+	for _, b := range bombs {
+		g.bombs[b.x*height+b.y] = b
+	}
+	g.grid = newGrid(width, height, g.bombs)
+	g.placed = true
+	g.state = InProgress
+	return g
+}
+
+func TestMiner_Flag(t *testing.T) {
+	game := newTestMiner(4, Position{0, 0}, Position{3, 3})
+	if err := game.Flag(0, 0); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if !game.Flagged(0, 0) {
+		t.Fatalf("expected cell to be flagged")
+	}
+	if game.Remaining() != 1 {
+		t.Fatalf("expected: %v, got: %v", 1, game.Remaining())
+	}
+	if _, state, err := game.Reveal(0, 0); !errors.Is(err, ErrCellFlagged) || state != InProgress {
+		t.Fatalf("expected: %v %v, got: %v %v", ErrCellFlagged, InProgress, err, state)
+	}
+	if err := game.Unflag(0, 0); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if game.Flagged(0, 0) || game.Remaining() != 2 {
+		t.Fatalf("expected cell to be unflagged")
+	}
+	if _, _, err := game.Reveal(2, 2); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if err := game.Flag(2, 2); !errors.Is(err, ErrCellRevealed) {
+		t.Fatalf("expected: %v, got: %v", ErrCellRevealed, err)
+	}
+	if err := game.Flag(4, 0); !errors.Is(err, ErrInvalidPosition) {
+		t.Fatalf("expected: %v, got: %v", ErrInvalidPosition, err)
+	}
+}
+
+func TestMiner_RevealSkipsFlagged(t *testing.T) {
+	game := newTestMiner(3, Position{0, 0})
+	if err := game.Flag(2, 0); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	_, state, err := game.Reveal(2, 2)
+	if err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if state != InProgress {
+		t.Fatalf("expected: %v, got: %v", InProgress, state)
+	}
+	if err := game.Unflag(2, 0); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if _, state, _ = game.Reveal(2, 0); state != Win {
+		t.Fatalf("expected: %v, got: %v", Win, state)
+	}
+}
+
+func TestMiner_RevealRevealed(t *testing.T) {
+	game := newTestMiner(3, Position{0, 0})
+	if _, _, err := game.Reveal(1, 1); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	counters, steps, undo := game.Counters(), len(game.steps), len(game.undo)
+	cells, state, err := game.Reveal(1, 1)
+	if err != nil || state != InProgress || len(cells) != 0 {
+		t.Fatalf("expected a no-op reveal, got: %v %v %v", len(cells), state, err)
+	}
+	if game.Counters() != counters || len(game.steps) != steps || len(game.undo) != undo {
+		t.Fatalf("expected the click not to be counted or recorded, got: %+v %v %v", game.Counters(), len(game.steps), len(game.undo))
+	}
+}
+
+func TestMiner_Chord(t *testing.T) {
+	tests := map[string]struct {
+		flags         []Position
+		chord         Position
+		expectedErr   error
+		expectedState GameState
+		expectedCells int
+	}{
+		"satisfied":     {flags: []Position{{0, 0}, {2, 2}}, chord: Position{1, 1}, expectedState: Win, expectedCells: 6},
+		"wrong flag":    {flags: []Position{{0, 0}, {0, 1}}, chord: Position{1, 1}, expectedState: Lose, expectedCells: 9},
+		"not satisfied": {flags: []Position{{0, 0}}, chord: Position{1, 1}, expectedState: InProgress, expectedCells: 0},
+		"not revealed":  {chord: Position{0, 1}, expectedErr: ErrCellNotRevealed, expectedState: InProgress},
+		"wrong x y":     {chord: Position{-1, 0}, expectedErr: ErrInvalidPosition, expectedState: InProgress},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			game := newTestMiner(3, Position{0, 0}, Position{2, 2})
+			if _, _, err := game.Reveal(1, 1); err != nil {
+				t.Fatalf("expected: %v, got: %v", nil, err)
+			}
+			for _, f := range tc.flags {
+				if err := game.Flag(f.x, f.y); err != nil {
+					t.Fatalf("expected: %v, got: %v", nil, err)
+				}
+			}
+			cells, state, err := game.Chord(tc.chord.x, tc.chord.y)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected: %v, got: %v", tc.expectedErr, err)
+			}
+			if state != tc.expectedState {
+				t.Fatalf("expected: %v, got: %v", tc.expectedState, state)
+			}
+			if len(cells) != tc.expectedCells {
+				t.Fatalf("expected: %v, got: %v", tc.expectedCells, len(cells))
+			}
+		})
+	}
+}
+
+func TestMiner_ChordNoop(t *testing.T) {
+	tests := map[string]struct {
+		flags []Position
+		chord Position
+	}{
+		"not satisfied":     {flags: []Position{{2, 1}}, chord: Position{3, 2}},
+		"empty cell":        {chord: Position{4, 4}},
+		"nothing to reveal": {flags: []Position{{2, 1}, {2, 2}, {2, 3}}, chord: Position{3, 2}},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			game := newTestMiner(5, Position{2, 0}, Position{2, 1}, Position{2, 2}, Position{2, 3}, Position{2, 4})
+			if _, _, err := game.Reveal(4, 4); err != nil {
+				t.Fatalf("expected: %v, got: %v", nil, err)
+			}
+			for _, f := range tc.flags {
+				if err := game.Flag(f.x, f.y); err != nil {
+					t.Fatalf("expected: %v, got: %v", nil, err)
+				}
+			}
+			counters, steps, undo := game.Counters(), len(game.steps), len(game.undo)
+			cells, state, err := game.Chord(tc.chord.x, tc.chord.y)
+			if err != nil || state != InProgress || len(cells) != 0 {
+				t.Fatalf("expected a no-op chord, got: %v %v %v", len(cells), state, err)
+			}
+			if game.Counters() != counters || len(game.steps) != steps || len(game.undo) != undo {
+				t.Fatalf("expected the chord not to be counted or recorded, got: %+v %v %v", game.Counters(), len(game.steps), len(game.undo))
+			}
+		})
+	}
+}
+
+func TestMiner_FirstClick(t *testing.T) {
+	tests := map[string]struct {
+		size          int
+		difficulty    int
+		mode          FirstClick
+		expectedErr   error
+		expectedState GameState
+		expectedCount int
+	}{
+		"safe cell":            {size: 10, difficulty: 99, mode: FirstClickSafe, expectedState: Win, expectedCount: 8},
+		"safe opening":         {size: 10, difficulty: 50, mode: FirstClickOpening, expectedState: InProgress, expectedCount: 0},
+		"opening fallback":     {size: 10, difficulty: 99, mode: FirstClickOpening, expectedState: Win, expectedCount: 8},
+		"no free cell":         {size: 10, difficulty: 100, mode: FirstClickSafe, expectedErr: ErrInvalidSettings},
+		"no free cell opening": {size: 10, difficulty: 100, mode: FirstClickOpening, expectedErr: ErrInvalidSettings},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			for i := 0; i < 20; i++ {
+				game := NewGame()
+				err := game.Start(tc.size, tc.difficulty, WithFirstClick(tc.mode))
+				if !errors.Is(err, tc.expectedErr) {
+					t.Fatalf("expected: %v, got: %v", tc.expectedErr, err)
+				}
+				if err != nil {
+					return
+				}
+				if len(game.bombs) != 0 {
+					t.Fatalf("expected no bombs before the first reveal, got: %v", len(game.bombs))
+				}
+				_, state, err := game.Reveal(5, 5)
+				if err != nil {
+					t.Fatalf("expected: %v, got: %v", nil, err)
+				}
+				if state != tc.expectedState {
+					t.Fatalf("expected: %v, got: %v", tc.expectedState, state)
+				}
+				if c := game.grid.getCell(5, 5).Count(); c != tc.expectedCount {
+					t.Fatalf("expected: %v, got: %v", tc.expectedCount, c)
+				}
+			}
+		})
+	}
+}
+
+func TestMiner_Seed(t *testing.T) {
+	first, second, other := NewGame(), NewGame(), NewGame()
+	if err := first.Start(16, 20, WithSeed(42)); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if err := second.Start(16, 20, WithSeed(42)); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if err := other.Start(16, 20, WithSeed(43)); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if first.Seed() != 0 || first.Replay().Seed != 0 {
+		t.Fatalf("expected the seed hidden during the game, got %d", first.Seed())
+	}
+	debug := NewGame()
+	if err := debug.Start(16, 20, WithSeed(42), WithDebug()); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if debug.Seed() != 42 || debug.Replay().Seed != 42 {
+		t.Fatalf("expected: %v, got: %v", 42, debug.Seed())
+	}
+	if !reflect.DeepEqual(first.bombs, second.bombs) {
+		t.Fatalf("expected the same layout for the same seed")
+	}
+	if reflect.DeepEqual(first.bombs, other.bombs) {
+		t.Fatalf("expected different layouts for different seeds")
+	}
+
+	first, second = NewGame(), NewGame()
+	first.Start(16, 20, WithSeed(42), WithFirstClick(FirstClickOpening))
+	second.Start(16, 20, WithSeed(42), WithFirstClick(FirstClickOpening))
+	first.Reveal(3, 4)
+	second.Reveal(3, 4)
+	if !reflect.DeepEqual(first.bombs, second.bombs) {
+		t.Fatalf("expected the same layout for the same seed and first click")
+	}
+}
+
+func TestMiner_StartRect(t *testing.T) {
+	tests := map[string]struct {
+		width, height int
+		difficulty    int
+		expectedErr   error
+		expectedBombs int
+	}{
+		"expert":       {width: 30, height: 16, difficulty: 20, expectedBombs: 96},
+		"tall":         {width: 5, height: 40, difficulty: 10, expectedBombs: 20},
+		"wrong width":  {width: 0, height: 16, difficulty: 20, expectedErr: ErrInvalidSettings},
+		"wrong height": {width: 30, height: 0, difficulty: 20, expectedErr: ErrInvalidSettings},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			game := NewGame()
+			err := game.StartRect(tc.width, tc.height, tc.difficulty)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected: %v, got: %v", tc.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if len(game.bombs) != tc.expectedBombs {
+				t.Fatalf("expected: %v, got: %v", tc.expectedBombs, len(game.bombs))
+			}
+			if s := game.Snapshot(); s.Width != tc.width || s.Height != tc.height || game.Difficulty() != tc.difficulty {
+				t.Fatalf("expected %dx%d at %d%%, got %dx%d at %d%%", tc.width, tc.height, tc.difficulty, s.Width, s.Height, game.Difficulty())
+			}
+			if _, _, err := game.Reveal(tc.width-1, tc.height-1); err != nil {
+				t.Fatalf("expected: %v, got: %v", nil, err)
+			}
+			if _, _, err := game.Reveal(tc.height, tc.width); !errors.Is(err, ErrInvalidPosition) {
+				t.Fatalf("expected: %v, got: %v", ErrInvalidPosition, err)
+			}
+		})
+	}
+}
+
+func TestMiner_RevealRect(t *testing.T) {
+	game := newTestRectMiner(4, 2, Position{3, 1})
+	cells, state, err := game.Reveal(0, 0)
+	if err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if state != InProgress {
+		t.Fatalf("expected: %v, got: %v", InProgress, state)
+	}
+	if len(cells) != 6 {
+		t.Fatalf("expected: %v, got: %v", 6, len(cells))
+	}
+	if c := game.grid.getCell(2, 0).Count(); c != 1 {
+		t.Fatalf("expected: %v, got: %v", 1, c)
+	}
+	if _, state, _ = game.Reveal(3, 0); state != Win {
+		t.Fatalf("expected: %v, got: %v", Win, state)
+	}
+}
+
+func TestMiner_StartWithMines(t *testing.T) {
+	tests := map[string]struct {
+		width, height int
+		mines         int
+		expectedErr   error
+	}{
+		"beginner":     {width: 9, height: 9, mines: 10},
+		"intermediate": {width: 16, height: 16, mines: 40},
+		"expert":       {width: 30, height: 16, mines: 99},
+		"all but one":  {width: 3, height: 3, mines: 8},
+		"too many":     {width: 3, height: 3, mines: 9, expectedErr: ErrInvalidSettings},
+		"negative":     {width: 3, height: 3, mines: -1, expectedErr: ErrInvalidSettings},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			game := NewGame()
+			err := game.StartRect(tc.width, tc.height, 0, WithMines(tc.mines))
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected: %v, got: %v", tc.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if game.bombsCount != tc.mines {
+				t.Fatalf("expected: %v, got: %v", tc.mines, game.bombsCount)
+			}
+			if len(game.bombs) != tc.mines {
+				t.Fatalf("expected: %v, got: %v", tc.mines, len(game.bombs))
+			}
+		})
+	}
+}
+
+func TestMiner_CellState(t *testing.T) {
+	game := newTestMiner(3, Position{0, 0}, Position{2, 2})
+	if err := game.Flag(2, 2); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if err := game.Flag(0, 2); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if err := game.Question(2, 0); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if game.Remaining() != 0 {
+		t.Fatalf("expected: %v, got: %v", 0, game.Remaining())
+	}
+	hidden, err := game.Cell(0, 0)
+	if err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if hidden.State() != Hidden || hidden.HasBomb() {
+		t.Fatalf("expected hidden cell without bomb, got: %v %v", hidden.State(), hidden.HasBomb())
+	}
+	if _, state, _ := game.Reveal(2, 0); state != InProgress {
+		t.Fatalf("expected: %v, got: %v", InProgress, state)
+	}
+	if _, state, _ := game.Reveal(0, 0); state != Lose {
+		t.Fatalf("expected: %v, got: %v", Lose, state)
+	}
+
+	expected := map[Position]CellState{
+		{0, 0}: Exploded,
+		{2, 2}: Flagged,
+		{0, 2}: WrongFlag,
+		{2, 0}: Revealed,
+		{1, 2}: Hidden,
+	}
+	for p, state := range expected {
+		cell, err := game.Cell(p.x, p.y)
+		if err != nil {
+			t.Fatalf("expected: %v, got: %v", nil, err)
+		}
+		if cell.State() != state {
+			t.Fatalf("%v expected: %v, got: %v", p, state, cell.State())
+		}
+	}
+	if err := game.Flag(2, 0); !errors.Is(err, ErrGameOver) {
+		t.Fatalf("expected: %v, got: %v", ErrGameOver, err)
+	}
+	if _, state, err := game.Reveal(1, 1); !errors.Is(err, ErrGameOver) || state != Lose {
+		t.Fatalf("expected: %v %v, got: %v %v", ErrGameOver, Lose, err, state)
+	}
+}
+
+func TestMiner_WinFlagsBombs(t *testing.T) {
+	game := newTestMiner(3, Position{0, 0})
+	cells, state, err := game.Reveal(2, 2)
+	if err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if state != Win {
+		t.Fatalf("expected: %v, got: %v", Win, state)
+	}
+	if len(cells) != 9 {
+		t.Fatalf("expected: %v, got: %v", 9, len(cells))
+	}
+	if cell, _ := game.Cell(0, 0); cell.State() != Flagged {
+		t.Fatalf("expected: %v, got: %v", Flagged, cell.State())
+	}
+	if game.Remaining() != 0 {
+		t.Fatalf("expected: %v, got: %v", 0, game.Remaining())
+	}
+}
+
+func TestMiner_Snapshot(t *testing.T) {
+	game := newTestMiner(3, Position{0, 0})
+	snapshot := game.Snapshot()
+	if snapshot.State != InProgress || snapshot.Elapsed != 0 || snapshot.Moves != 0 || snapshot.Remaining != 1 {
+		t.Fatalf("unexpected snapshot before the first move: %+v", snapshot)
+	}
+	if err := game.Flag(1, 0); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if _, _, err := game.Reveal(0, 1); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	snapshot = game.Snapshot()
+	if snapshot.Width != 3 || snapshot.Height != 3 || len(snapshot.Cells) != 3 || len(snapshot.Cells[0]) != 3 {
+		t.Fatalf("unexpected snapshot size: %+v", snapshot)
+	}
+	if snapshot.Moves != 2 {
+		t.Fatalf("expected: %v, got: %v", 2, snapshot.Moves)
+	}
+	for x, column := range snapshot.Cells {
+		for y, cell := range column {
+			if cell.HasBomb() {
+				t.Fatalf("expected hidden bombs not to be exposed, got bomb at %v %v", x, y)
+			}
+			if cell.State() != Revealed && cell.Count() != 0 {
+				t.Fatalf("expected hidden counts not to be exposed, got count at %v %v", x, y)
+			}
+		}
+	}
+	if cell := snapshot.Cells[0][1]; cell.State() != Revealed || cell.Count() != 1 {
+		t.Fatalf("expected revealed cell with count 1, got: %v %v", cell.State(), cell.Count())
+	}
+	if cell := snapshot.Cells[1][0]; cell.State() != Flagged {
+		t.Fatalf("expected: %v, got: %v", Flagged, cell.State())
+	}
+
+	game.Reveal(0, 0)
+	if game.State() != Lose {
+		t.Fatalf("expected: %v, got: %v", Lose, game.State())
+	}
+	elapsed := game.Elapsed()
+	if elapsed < 0 || game.Elapsed() != elapsed {
+		t.Fatalf("expected the timer to be stopped, got: %v %v", elapsed, game.Elapsed())
+	}
+	if cell := game.Board()[0][0]; cell.State() != Exploded || !cell.HasBomb() {
+		t.Fatalf("expected exploded bomb, got: %v %v", cell.State(), cell.HasBomb())
+	}
+}
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func TestMiner_Timer(t *testing.T) {
+	clock := &testClock{now: time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)}
+	game := newTestMiner(4, Position{0, 0}, Position{3, 3})
+	game.options = newOptions([]Option{WithClock(clock.Now)})
+
+	clock.Advance(time.Minute)
+	if game.Elapsed() != 0 || !game.StartedAt().IsZero() {
+		t.Fatalf("expected the timer to wait for the first reveal, got: %v", game.Elapsed())
+	}
+	if err := game.Flag(3, 0); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if game.Elapsed() != 0 {
+		t.Fatalf("expected flags not to start the timer, got: %v", game.Elapsed())
+	}
+	if _, _, err := game.Reveal(1, 1); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	clock.Advance(3 * time.Second)
+	if game.Elapsed() != 3*time.Second {
+		t.Fatalf("expected: %v, got: %v", 3*time.Second, game.Elapsed())
+	}
+	if err := game.Flag(0, 0); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if _, _, err := game.Chord(1, 1); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	clock.Advance(2 * time.Second)
+	if _, state, _ := game.Reveal(3, 3); state != Lose {
+		t.Fatalf("expected: %v, got: %v", Lose, state)
+	}
+	clock.Advance(time.Hour)
+	if game.Elapsed() != 5*time.Second {
+		t.Fatalf("expected: %v, got: %v", 5*time.Second, game.Elapsed())
+	}
+	if game.EndedAt().Sub(game.StartedAt()) != 5*time.Second {
+		t.Fatalf("expected: %v, got: %v", 5*time.Second, game.EndedAt().Sub(game.StartedAt()))
+	}
+	expected := Counters{LeftClicks: 2, RightClicks: 2, Chords: 1}
+	if game.Counters() != expected {
+		t.Fatalf("expected: %+v, got: %+v", expected, game.Counters())
+	}
+	if game.Moves() != 5 {
+		t.Fatalf("expected: %v, got: %v", 5, game.Moves())
+	}
+}
+
+func TestMiner_BBBV(t *testing.T) {
+	tests := map[string]struct {
+		width, height int
+		bombs         []Position
+		expected      int
+	}{
+		"one opening":         {width: 3, height: 3, bombs: []Position{{0, 0}}, expected: 1},
+		"no openings":         {width: 3, height: 3, bombs: []Position{{1, 1}}, expected: 8},
+		"opening and islands": {width: 6, height: 1, bombs: []Position{{0, 0}, {3, 0}}, expected: 3},
+		"two openings":        {width: 7, height: 1, bombs: []Position{{3, 0}}, expected: 2},
+		"island only":         {width: 3, height: 1, bombs: []Position{{0, 0}, {2, 0}}, expected: 1},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			game := newTestRectMiner(tc.width, tc.height, tc.bombs...)
+			if bbbv := game.BBBV(); bbbv != tc.expected {
+				t.Fatalf("expected: %v, got: %v", tc.expected, bbbv)
+			}
+		})
+	}
+}
+
+func TestMiner_SaveLoad(t *testing.T) {
+	clock := &testClock{now: time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)}
+	original := NewGame()
+	if err := original.StartRect(8, 6, 0, WithMines(6), WithSeed(7), WithFirstClick(FirstClickOpening), WithClock(clock.Now)); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if _, _, err := original.Reveal(4, 3); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	for _, p := range original.bombs {
+		original.Flag(p.x, p.y)
+		break
+	}
+	clock.Advance(42 * time.Second)
+
+	var buf bytes.Buffer
+	if err := original.Save(&buf); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	loaded := NewGame()
+	if err := loaded.Load(&buf, WithClock(clock.Now)); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if !reflect.DeepEqual(original.Snapshot(), loaded.Snapshot()) {
+		t.Fatalf("expected: %+v, got: %+v", original.Snapshot(), loaded.Snapshot())
+	}
+	if original.Seed() != loaded.Seed() || original.BBBV() != loaded.BBBV() {
+		t.Fatalf("expected the same seed and layout")
+	}
+
+	for x := 0; x < original.width; x++ {
+		for y := 0; y < original.height; y++ {
+			if original.grid.getCell(x, y).HasBomb() || original.Flagged(x, y) {
+				continue
+			}
+			expectedCells, expectedState, expectedErr := original.Reveal(x, y)
+			cells, state, err := loaded.Reveal(x, y)
+			if state != expectedState || !errors.Is(err, expectedErr) || len(cells) != len(expectedCells) {
+				t.Fatalf("expected: %v %v %v, got: %v %v %v", len(expectedCells), expectedState, expectedErr, len(cells), state, err)
+			}
+		}
+	}
+	if loaded.State() != Win {
+		t.Fatalf("expected: %v, got: %v", Win, loaded.State())
+	}
+}
+
+func TestMiner_SaveLoadUnplaced(t *testing.T) {
+	original := NewGame()
+	if err := original.Start(10, 20, WithSeed(3), WithFirstClick(FirstClickSafe)); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	var buf bytes.Buffer
+	if err := original.Save(&buf); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	loaded := NewGame()
+	if err := loaded.Load(&buf); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	original.Reveal(2, 2)
+	loaded.Reveal(2, 2)
+	if !reflect.DeepEqual(original.bombs, loaded.bombs) {
+		t.Fatalf("expected the same layout after the first reveal")
+	}
+}
+
+func TestMiner_LoadErrors(t *testing.T) {
+	tests := map[string]struct {
+		data        string
+		expectedErr error
+	}{
+		"not json":      {data: "{", expectedErr: ErrCorruptSave},
+		"wrong version": {data: `{"version": 2}`, expectedErr: ErrSaveVersion},
+		"wrong cells":   {data: `{"version": 1, "width": 2, "height": 2, "mines": 1, "placed": true, "bombs": [0], "cells": [0], "state": "in progress"}`, expectedErr: ErrCorruptSave},
+		"wrong bomb":    {data: `{"version": 1, "width": 1, "height": 2, "mines": 1, "placed": true, "bombs": [5], "cells": [0, 0], "state": "in progress"}`, expectedErr: ErrCorruptSave},
+		"wrong state":   {data: `{"version": 1, "width": 1, "height": 2, "mines": 1, "placed": true, "bombs": [1], "cells": [0, 0], "state": "paused"}`, expectedErr: ErrCorruptSave},
+		"correct":       {data: `{"version": 1, "width": 1, "height": 3, "mines": 1, "placed": true, "bombs": [1], "cells": [1, 0, 0], "state": "in progress"}`},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := NewGame().Load(strings.NewReader(tc.data))
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected: %v, got: %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestMiner_LoadTampered(t *testing.T) {
+	tests := map[string]func(s *save){
+		"revealed bomb":         func(s *save) { s.Cells[4] = Revealed },
+		"exploded in progress":  func(s *save) { s.Cells[4] = Exploded },
+		"mine on safe cell":     func(s *save) { s.State, s.Cells[0], s.Cells[4] = Lose, Mine, Exploded },
+		"wrong flag on bomb":    func(s *save) { s.State, s.Cells[4], s.Cells[5] = Lose, WrongFlag, Exploded },
+		"lost without bomb":     func(s *save) { s.State = Lose },
+		"won with hidden cells": func(s *save) { s.State = Win },
+		"all revealed":          func(s *save) { s.Cells[0], s.Cells[1], s.Cells[2], s.Cells[3] = Revealed, Revealed, Revealed, Revealed },
+		"revealed unplaced":     func(s *save) { s.Placed, s.Bombs = false, nil },
+	}
+
+	for name, tamper := range tests {
+		t.Run(name, func(t *testing.T) {
+			game := newTestMiner(4, undoWall...)
+			if _, _, err := game.Reveal(3, 0); err != nil {
+				t.Fatalf("expected: %v, got: %v", nil, err)
+			}
+			var buf bytes.Buffer
+			if err := game.Save(&buf); err != nil {
+				t.Fatalf("expected: %v, got: %v", nil, err)
+			}
+			var s save
+			if err := json.Unmarshal(buf.Bytes(), &s); err != nil {
+				t.Fatalf("expected: %v, got: %v", nil, err)
+			}
+			if err := NewGame().Load(bytes.NewReader(buf.Bytes())); err != nil {
+				t.Fatalf("expected the untouched save to load, got: %v", err)
+			}
+			tamper(&s)
+			data, err := json.Marshal(s)
+			if err != nil {
+				t.Fatalf("expected: %v, got: %v", nil, err)
+			}
+			if err := NewGame().Load(bytes.NewReader(data)); !errors.Is(err, ErrCorruptSave) {
+				t.Fatalf("expected: %v, got: %v", ErrCorruptSave, err)
+			}
+		})
+	}
+}
+
+func TestMiner_SaveNotStarted(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewGame().Save(&buf); !errors.Is(err, ErrNotStarted) {
+		t.Fatalf("expected: %v, got: %v", ErrNotStarted, err)
+	}
+}
+
+// undoWall is the 4x4 layout with the column of bombs, so the reveal on the right keeps the left column hidden
+var undoWall = []Position{{1, 0}, {1, 1}, {1, 2}, {1, 3}}
+
+func TestMiner_UndoRedo(t *testing.T) {
+	game := newTestMiner(4, undoWall...)
+	initial := game.Snapshot()
+	if _, _, err := game.Undo(); !errors.Is(err, ErrNothingToUndo) {
+		t.Fatalf("expected: %v, got: %v", ErrNothingToUndo, err)
+	}
+	if err := game.Flag(1, 0); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	afterFlag := game.Snapshot()
+	if _, _, err := game.Reveal(3, 0); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	afterReveal := game.Snapshot()
+	if !game.Eligible() {
+		t.Fatalf("expected the game to be eligible before undo")
+	}
+
+	cells, state, err := game.Undo()
+	if err != nil || state != InProgress {
+		t.Fatalf("expected: %v %v, got: %v %v", nil, InProgress, err, state)
+	}
+	if len(cells) != 8 {
+		t.Fatalf("expected: %v, got: %v", 8, len(cells))
+	}
+	if !reflect.DeepEqual(game.Board(), afterFlag.Cells) || game.Remaining() != afterFlag.Remaining {
+		t.Fatalf("expected the board after the flag")
+	}
+	if _, _, err := game.Undo(); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if !reflect.DeepEqual(game.Board(), initial.Cells) || game.Remaining() != initial.Remaining {
+		t.Fatalf("expected the initial board")
+	}
+	if game.Eligible() {
+		t.Fatalf("expected the game not to be eligible after undo")
+	}
+
+	if _, _, err := game.Redo(); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if _, _, err := game.Redo(); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if !reflect.DeepEqual(game.Board(), afterReveal.Cells) {
+		t.Fatalf("expected the board after the reveal")
+	}
+	if _, _, err := game.Redo(); !errors.Is(err, ErrNothingToRedo) {
+		t.Fatalf("expected: %v, got: %v", ErrNothingToRedo, err)
+	}
+
+	game.Undo()
+	if err := game.Question(0, 0); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if _, _, err := game.Redo(); !errors.Is(err, ErrNothingToRedo) {
+		t.Fatalf("expected a new move to drop the redo history, got: %v", err)
+	}
+}
+
+func TestMiner_UndoLose(t *testing.T) {
+	tests := map[string]struct {
+		opts        []Option
+		expectedErr error
+		expected    GameState
+	}{
+		"practice": {opts: []Option{WithPractice()}, expected: InProgress},
+		"normal":   {expectedErr: ErrGameOver, expected: Lose},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			game := newTestMiner(4, undoWall...)
+			game.options = newOptions(tc.opts)
+			game.Reveal(3, 0)
+			before := game.Snapshot()
+			if _, state, _ := game.Reveal(1, 0); state != Lose {
+				t.Fatalf("expected: %v, got: %v", Lose, state)
+			}
+			_, state, err := game.Undo()
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected: %v, got: %v", tc.expectedErr, err)
+			}
+			if state != tc.expected || game.State() != tc.expected {
+				t.Fatalf("expected: %v, got: %v", tc.expected, state)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(game.Board(), before.Cells) || !game.EndedAt().IsZero() {
+				t.Fatalf("expected the board before the losing click")
+			}
+			if _, state, _ := game.Reveal(0, 0); state != InProgress {
+				t.Fatalf("expected: %v, got: %v", InProgress, state)
+			}
+		})
+	}
+}
+
+func TestMiner_UndoFirstReveal(t *testing.T) {
+	game := NewGame()
+	if err := game.Start(10, 20, WithSeed(5), WithFirstClick(FirstClickOpening)); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	game.Reveal(2, 2)
+	layout := game.bombs
+	if _, _, err := game.Undo(); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if len(game.bombs) != 0 {
+		t.Fatalf("expected bombs to be placed again on the first reveal, got: %v", len(game.bombs))
+	}
+	game.Redo()
+	if !reflect.DeepEqual(game.bombs, layout) {
+		t.Fatalf("expected the same layout after redo")
+	}
+	game.Undo()
+	game.Reveal(2, 2)
+	if !reflect.DeepEqual(game.bombs, layout) {
+		t.Fatalf("expected the same layout for the same seed and first click")
+	}
+}
+
+func TestMiner_UndoChord(t *testing.T) {
+	game := newTestMiner(4, undoWall...)
+	game.Reveal(2, 0)
+	game.Flag(1, 0)
+	game.Flag(1, 1)
+	before := game.Snapshot()
+	cells, _, err := game.Chord(2, 0)
+	if err != nil || len(cells) == 0 {
+		t.Fatalf("expected the chord to reveal cells, got: %v %v", cells, err)
+	}
+	undone, _, err := game.Undo()
+	if err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if len(undone) != len(cells) {
+		t.Fatalf("expected: %v, got: %v", len(cells), len(undone))
+	}
+	if !reflect.DeepEqual(game.Board(), before.Cells) || game.Remaining() != before.Remaining {
+		t.Fatalf("expected the board before the chord")
+	}
+}
+
+func TestMiner_SaveLoadAssisted(t *testing.T) {
+	game := newTestMiner(4, undoWall...)
+	game.Reveal(3, 0)
+	game.Undo()
+	var buf bytes.Buffer
+	if err := game.Save(&buf); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	loaded := NewGame()
+	if err := loaded.Load(&buf); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if loaded.Eligible() {
+		t.Fatalf("expected the loaded game not to be eligible")
+	}
+	if _, _, err := loaded.Undo(); !errors.Is(err, ErrNothingToUndo) {
+		t.Fatalf("expected: %v, got: %v", ErrNothingToUndo, err)
+	}
+}
+
+func TestReplay_RoundTrip(t *testing.T) {
+	clock := &testClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	game := NewGame()
+	if err := game.Start(10, 15, WithSeed(7), WithFirstClick(FirstClickOpening), WithClock(clock.Now), WithDebug()); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	clock.Advance(time.Second)
+	game.Reveal(5, 5)
+	var hidden, safe Position
+	for _, cell := range game.cells() {
+		if cell.state != Hidden {
+			continue
+		}
+		if cell.bomb {
+			hidden = cell.Position
+		} else {
+			safe = cell.Position
+		}
+	}
+	clock.Advance(time.Second)
+	game.Flag(hidden.x, hidden.y)
+	game.Question(hidden.x, hidden.y)
+	game.Unflag(hidden.x, hidden.y)
+	game.Undo()
+	game.Redo()
+	clock.Advance(time.Second)
+	game.Reveal(safe.x, safe.y)
+	game.Reveal(-1, 0)
+
+	replay := game.Replay()
+	if len(replay.Steps) != 7 {
+		t.Fatalf("expected: %v, got: %v", 7, len(replay.Steps))
+	}
+	if replay.Steps[6].At != 3*time.Second {
+		t.Fatalf("expected: %v, got: %v", 3*time.Second, replay.Steps[6].At)
+	}
+	var buf bytes.Buffer
+	if err := replay.Write(&buf); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	read, err := ReadReplay(&buf)
+	if err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if !reflect.DeepEqual(read, replay) {
+		t.Fatalf("expected: %+v, got: %+v", replay, read)
+	}
+
+	played := NewGame()
+	if err := read.Play(played); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if !reflect.DeepEqual(played.Board(), game.Board()) || !reflect.DeepEqual(played.bombs, game.bombs) {
+		t.Fatalf("expected the same board after playback")
+	}
+	if played.State() != game.State() || played.Counters() != game.Counters() {
+		t.Fatalf("expected: %v %v, got: %v %v", game.State(), game.Counters(), played.State(), played.Counters())
+	}
+}
+
+func TestReplay_SaveLoad(t *testing.T) {
+	game := newTestMiner(4, undoWall...)
+	game.Flag(1, 0)
+	var buf bytes.Buffer
+	if err := game.Save(&buf); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	loaded := NewGame()
+	if err := loaded.Load(&buf); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	loaded.Reveal(3, 0)
+	steps := loaded.Replay().Steps
+	expected := []Action{ActionFlag, ActionReveal}
+	if len(steps) != len(expected) {
+		t.Fatalf("expected: %v, got: %v", len(expected), len(steps))
+	}
+	for i, s := range steps {
+		if s.Action != expected[i] {
+			t.Fatalf("expected: %v, got: %v", expected[i], s.Action)
+		}
+	}
+}
+
+func TestReadReplay_Errors(t *testing.T) {
+	tests := map[string]struct {
+		data        string
+		expectedErr error
+	}{
+		"not json":       {data: "{", expectedErr: ErrCorruptReplay},
+		"wrong version":  {data: `{"version": 2}`, expectedErr: ErrReplayVersion},
+		"wrong board":    {data: `{"version": 1, "width": 2, "height": 2, "mines": 4}`, expectedErr: ErrCorruptReplay},
+		"wrong action":   {data: `{"version": 1, "width": 2, "height": 2, "mines": 1}` + "\n" + `{"action": "jump"}`, expectedErr: ErrCorruptReplay},
+		"wrong position": {data: `{"version": 1, "width": 2, "height": 2, "mines": 1}` + "\n" + `{"action": "reveal", "x": 2}`, expectedErr: ErrCorruptReplay},
+		"wrong order":    {data: `{"version": 1, "width": 2, "height": 2, "mines": 1}` + "\n" + `{"action": "undo", "at": 5}` + "\n" + `{"action": "redo", "at": 1}`, expectedErr: ErrCorruptReplay},
+		"correct":        {data: `{"version": 1, "width": 2, "height": 2, "mines": 1}` + "\n" + `{"action": "reveal", "x": 1, "y": 1}`},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ReadReplay(strings.NewReader(tc.data))
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected: %v, got: %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestMiner_NoGuess(t *testing.T) {
+	for seed := int64(1); seed <= 10; seed++ {
+		game := NewGame()
+		if err := game.StartRect(30, 16, 0, WithMines(99), WithSeed(seed), WithNoGuess(), WithDebug()); err != nil {
+			t.Fatalf("expected: %v, got: %v", nil, err)
+		}
+		if _, _, err := game.Reveal(15, 8); err != nil {
+			t.Fatalf("expected: %v, got: %v", nil, err)
+		}
+		if len(game.bombs) != 99 {
+			t.Fatalf("expected: %v, got: %v", 99, len(game.bombs))
+		}
+		if _, solved := analysis.Solve(game.layout(game.bombs), game.grid.index(15, 8)); !solved {
+			t.Fatalf("seed %d: expected the board to be solvable without guessing", seed)
+		}
+
+		// the replay reproduces the same layout
+		replayed := NewGame()
+		if err := game.Replay().Play(replayed); err != nil {
+			t.Fatalf("expected: %v, got: %v", nil, err)
+		}
+		if !reflect.DeepEqual(replayed.bombs, game.bombs) {
+			t.Fatalf("seed %d: expected the replayed layout to match", seed)
+		}
+	}
+}
+
+func TestMiner_NoGuessBudget(t *testing.T) {
+	// the single mine is on either side of the first click, it is always a guess
+	game := NewGame()
+	if err := game.StartRect(3, 1, 0, WithMines(1), WithNoGuess(), WithGenerationBudget(10, time.Second)); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if _, state, err := game.Reveal(1, 0); !errors.Is(err, ErrGenerationBudget) || state != InProgress {
+		t.Fatalf("expected: %v, got: %v", ErrGenerationBudget, err)
+	}
+	if game.placed || game.Moves() != 0 || len(game.Replay().Steps) != 0 {
+		t.Fatalf("expected the failed reveal to leave the game untouched")
+	}
+	if _, _, err := game.Undo(); !errors.Is(err, ErrNothingToUndo) {
+		t.Fatalf("expected: %v, got: %v", ErrNothingToUndo, err)
+	}
+}
+
+func TestMiner_SaveLoadNoGuess(t *testing.T) {
+	game := NewGame()
+	if err := game.StartRect(9, 9, 0, WithMines(10), WithSeed(1), WithNoGuess()); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	var buf bytes.Buffer
+	if err := game.Save(&buf); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	loaded := NewGame()
+	if err := loaded.Load(&buf); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	game.Reveal(4, 4)
+	loaded.Reveal(4, 4)
+	if !reflect.DeepEqual(loaded.bombs, game.bombs) {
+		t.Fatalf("expected the loaded game to place the same no-guess layout")
+	}
+}
+
+func TestMiner_Hint(t *testing.T) {
+	game := newTestMiner(4, undoWall...)
+	game.Reveal(3, 0)
+	game.Flag(0, 0) // the wrong flag away from the numbers does not change the hint
+	moves := game.Moves()
+	hint, err := game.Hint()
+	if err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if hint.X != 1 || hint.Y != 0 || !hint.Mine || hint.Guess {
+		t.Fatalf("expected the mine at 1 0, got: %+v", hint)
+	}
+	if hint.Reason != "this 2 has no other place for its mines" || len(hint.Because) != 1 || hint.Because[0].X() != 2 || hint.Because[0].Y() != 0 {
+		t.Fatalf("unexpected reason: %+v", hint)
+	}
+	if game.Eligible() || game.Counters().Hints != 1 || game.Moves() != moves {
+		t.Fatalf("expected the hint to be counted apart from the moves and the game to be not eligible")
+	}
+	steps := game.Replay().Steps
+	if last := steps[len(steps)-1]; last.Action != ActionHint || last.X != 1 || last.Y != 0 {
+		t.Fatalf("expected: %v, got: %v", ActionHint, last)
+	}
+
+	game.Reveal(0, 1)
+	game.Reveal(0, 2)
+	game.Unflag(0, 0)
+	game.Reveal(0, 0)
+	game.Reveal(0, 3)
+	if _, err := game.Hint(); !errors.Is(err, ErrGameOver) {
+		t.Fatalf("expected: %v, got: %v", ErrGameOver, err)
+	}
+}
+
+func TestMiner_HintWrongFlag(t *testing.T) {
+	game := NewGame()
+	if err := game.StartRect(9, 9, 0, WithMines(10), WithSeed(1), WithFirstClick(FirstClickOpening)); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	game.Reveal(4, 4)
+	if game.grid.getCell(3, 2).bomb {
+		t.Fatal("expected the safe cell to flag")
+	}
+	game.Flag(3, 2)
+	hint, err := game.Hint()
+	if err == nil && hint.X == 3 && hint.Y == 2 {
+		t.Fatalf("expected the wrong flag not to be revealed by the hint, got: %+v", hint)
+	}
+	// the second flag next to the 1 at 3 3 contradicts it
+	game.Flag(2, 2)
+	if _, err := game.Hint(); !errors.Is(err, ErrNoHint) {
+		t.Fatalf("expected: %v, got: %v", ErrNoHint, err)
+	}
+}
+
+func TestMiner_HintFirstReveal(t *testing.T) {
+	game := NewGame()
+	if err := game.StartRect(9, 9, 0, WithMines(10), WithFirstClick(FirstClickSafe), WithSeed(1)); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	hint, err := game.Hint()
+	if err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if hint.X != 4 || hint.Y != 4 || hint.Mine || hint.Guess {
+		t.Fatalf("expected the safe center, got: %+v", hint)
+	}
+
+	replayed := NewGame()
+	if err := game.Replay().Play(replayed); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if replayed.Counters().Hints != 1 || replayed.Eligible() {
+		t.Fatalf("expected the replayed hint to be counted")
+	}
+}
+
+func TestMiner_Stats(t *testing.T) {
+	game := NewGame()
+	if err := game.StartRect(16, 16, 0, WithMines(40), WithSeed(2), WithNoGuess()); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if stats := game.Stats(); stats.BBBV != 0 || stats.Openings != nil {
+		t.Fatalf("expected no stats before the bombs are placed, got: %+v", stats)
+	}
+	game.Reveal(3, 12)
+	stats := game.Stats()
+	if stats.BBBV != game.BBBV() || stats.NeedsGuess || len(stats.Openings) == 0 {
+		t.Fatalf("unexpected stats of the no-guess board: %+v", stats)
+	}
+}
+
+// TestMiner_Concurrent plays many games from many goroutines at once, run with -race to check the locking
+func TestMiner_Concurrent(t *testing.T) {
+	const games, players = 8, 8
+	var wg sync.WaitGroup
+	for i := 0; i < games; i++ {
+		g := NewGame()
+		if err := g.StartRect(16, 16, 0, WithMines(40), WithSeed(int64(i+1)), WithFirstClick(FirstClickOpening)); err != nil {
+			t.Fatalf("expected: %v, got: %v", nil, err)
+		}
+		for p := 0; p < players; p++ {
+			wg.Add(1)
+			go func(g *Miner, p int) {
+				defer wg.Done()
+				for n := 0; n < 64; n++ {
+					x, y := (p*7+n*5)%16, (p*3+n*11)%16
+					switch n % 8 {
+					case 0:
+						g.Reveal(x, y)
+					case 1:
+						g.Flag(x, y)
+					case 2:
+						g.Chord(x, y)
+					case 3:
+						g.Unflag(x, y)
+					case 4:
+						g.Snapshot()
+					case 5:
+						g.Hint()
+					case 6:
+						g.Undo()
+					case 7:
+						g.Stats()
+						g.Save(io.Discard)
+					}
+				}
+			}(g, p)
+		}
+	}
+	wg.Wait()
+}
+
+// TestMiner_ConcurrentSeeds checks that the games played at once get the layouts of their seeds
+func TestMiner_ConcurrentSeeds(t *testing.T) {
+	boards := make([][][]Cell, 16)
+	var wg sync.WaitGroup
+	for i := range boards {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			g := NewGame()
+			g.StartRect(16, 16, 0, WithMines(40), WithSeed(int64(i%2+1)), WithFirstClick(FirstClickOpening))
+			g.Reveal(8, 8)
+			boards[i] = g.Board()
+		}(i)
+	}
+	wg.Wait()
+	for i := 2; i < len(boards); i++ {
+		if !reflect.DeepEqual(boards[i], boards[i%2]) {
+			t.Fatalf("game %d: expected the board of seed %d", i, i%2+1)
+		}
+	}
+}
+
+func TestMiner_Revealed(t *testing.T) {
+	game := NewGame()
+	if err := game.StartRect(9, 9, 0, WithMines(10), WithSeed(7), WithFirstClick(FirstClickOpening), WithPractice()); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	count := func() int {
+		n := 0
+		for _, column := range game.Board() {
+			for _, cell := range column {
+				if cell.State() == Revealed {
+					n++
+				}
+			}
+		}
+		return n
+	}
+	if game.Revealed() != 0 {
+		t.Fatalf("expected: %v, got: %v", 0, game.Revealed())
+	}
+	cells, _, _ := game.Reveal(4, 4)
+	if game.Revealed() != len(cells) || game.Revealed() != count() {
+		t.Fatalf("expected: %v, got: %v", count(), game.Revealed())
+	}
+	game.Undo()
+	if game.Revealed() != 0 {
+		t.Fatalf("expected: %v after undo, got: %v", 0, game.Revealed())
+	}
+	game.Redo()
+	if game.Revealed() != count() {
+		t.Fatalf("expected: %v after redo, got: %v", count(), game.Revealed())
+	}
+}
+
+func TestMiner_Layout(t *testing.T) {
+	game := NewGame()
+	game.StartRect(9, 9, 0, WithMines(10), WithSeed(7))
+	if _, err := game.Layout(); !errors.Is(err, ErrDebugDisabled) {
+		t.Fatalf("expected: %v, got: %v", ErrDebugDisabled, err)
+	}
+
+	game.StartRect(9, 9, 0, WithMines(10), WithSeed(7), WithDebug())
+	layout, err := game.Layout()
+	if err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	mines := 0
+	for i, mine := range layout.Mines {
+		if mine {
+			mines++
+			if _, ok := game.bombs[i]; !ok {
+				t.Fatalf("expected cell %d to be a bomb", i)
+			}
+		}
+	}
+	if mines != 10 || layout.Width != 9 || layout.Height != 9 {
+		t.Fatalf("expected the 9x9 layout with %v mines, got %vx%v with %v", 10, layout.Width, layout.Height, mines)
+	}
+}
+
+func TestGrid_NearCells(t *testing.T) {
+	grid := newGrid(3, 2, nil)
+	tests := map[string]struct {
+		x, y     int
+		expected []int
+	}{
+		"corner": {x: 0, y: 0, expected: []int{1, 2, 3}},
+		"edge":   {x: 1, y: 1, expected: []int{0, 1, 2, 4, 5}},
+		"other":  {x: 2, y: 0, expected: []int{2, 3, 5}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if near := grid.nearCells(tc.x, tc.y, nil); !reflect.DeepEqual(near, tc.expected) {
+				t.Fatalf("expected: %v, got: %v", tc.expected, near)
+			}
+		})
+	}
+	var buf [8]int
+	if allocs := testing.AllocsPerRun(100, func() { grid.nearCells(1, 1, buf[:0]) }); allocs != 0 {
+		t.Fatalf("expected no allocations, got: %v", allocs)
+	}
+}
+
+// TestMiner_RevealHuge floods the whole 1000x1000 board with one click
+func TestMiner_RevealHuge(t *testing.T) {
+	game := NewGame()
+	if err := game.StartRect(1000, 1000, 0, WithMines(1), WithSeed(1), WithFirstClick(FirstClickOpening)); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	cells, state, err := game.Reveal(500, 500)
+	runtime.ReadMemStats(&after)
+	if err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if state != Win || game.Revealed() != 1000*1000-1 || len(cells) != 1000*1000 {
+		t.Fatalf("expected the win with every cell changed, got %v, %d revealed, %d cells", state, game.Revealed(), len(cells))
+	}
+	// the grid placed on the first reveal and the returned cells take 40 bytes per cell each,
+	// the fill and the undo entry only a few bytes per cell
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 96<<20 {
+		t.Fatalf("expected at most 96MB allocated, got %dMB", allocated>>20)
+	}
+	if a := game.undo[0]; len(a.revealed) != 1000*1000-1 || len(a.changes) != 1 {
+		t.Fatalf("expected the flood recorded as the revealed indexes, got %d indexes, %d changes", len(a.revealed), len(a.changes))
+	}
+}
+
+// TestMiner_FloodResize reuses the game for a bigger board of the same bitset size, the queue must fit the new board
+func TestMiner_FloodResize(t *testing.T) {
+	game := NewGame()
+	for _, size := range []int{3, 7} {
+		if err := game.Start(size, 0, WithMines(1), WithFirstClick(FirstClickOpening)); err != nil {
+			t.Fatalf("expected: %v, got: %v", nil, err)
+		}
+		if _, _, err := game.Reveal(size/2, size/2); err != nil {
+			t.Fatalf("expected: %v, got: %v", nil, err)
+		}
+		if c := cap(game.flood.cells); c != size*size {
+			t.Fatalf("expected the queue sized for %d cells, got: %v", size*size, c)
+		}
+	}
+}
+
+// marathonStart returns the empty cell of the 1000x1000 board with the seed, the bombs are placed on StartRect
+func marathonStart(b *testing.B, mines int) (int, int) {
+	b.Helper()
+	game := NewGame()
+	if err := game.StartRect(1000, 1000, 0, WithMines(mines), WithSeed(1), WithDebug()); err != nil {
+		b.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	for x, column := range game.grid.cells {
+		for y, cell := range column {
+			if !cell.bomb && cell.count == 0 {
+				return x, y
+			}
+		}
+	}
+	b.Fatal("no empty cell")
+	return 0, 0
+}
+
+// BenchmarkMiner_RevealMarathon floods the 1000x1000 board with few mines, almost every cell is revealed by one click
+func BenchmarkMiner_RevealMarathon(b *testing.B) {
+	x, y := marathonStart(b, 1000)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		game := NewGame()
+		game.StartRect(1000, 1000, 0, WithMines(1000), WithSeed(1))
+		b.StartTimer()
+		if _, state, err := game.Reveal(x, y); err != nil || state != InProgress && state != Win {
+			b.Fatalf("expected the opening, got %v %v", state, err)
+		}
+	}
+}
+
+// BenchmarkMiner_RevealDense reveals the small openings of the 1000x1000 board with the expert density one by one
+func BenchmarkMiner_RevealDense(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		game := NewGame()
+		game.StartRect(1000, 1000, 0, WithMines(206000), WithSeed(1))
+		b.StartTimer()
+		for x := 0; x < 1000; x += 10 {
+			for y := 0; y < 1000; y += 10 {
+				if c := game.grid.cells[x][y]; !c.bomb && c.state == Hidden {
+					game.Reveal(x, y)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkMiner_StartMarathon(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		game := NewGame()
+		if err := game.StartRect(1000, 1000, 0, WithMines(206000), WithSeed(1)); err != nil {
+			b.Fatalf("expected: %v, got: %v", nil, err)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package game
+
+import (
+	"errors"
+
+	"github.com/miner/solver"
+)
+
+var ErrNoHint = errors.New("no hint for this board")
+
+// Hint is the suggested next move with the short reason for it
+type Hint struct {
+	X, Y int
+	// Mine reports whether the cell is a certain mine, otherwise it is certainly safe
+	Mine bool
+	// Guess reports that no move is certain, the cell is the one least likely to be a mine
+	Guess bool
+	// Probability is the mine probability of the guessed cell
+	Probability float64
+	// Because is the revealed cells the reason is about
+	Because []Cell
+	Reason  string
+}
+
+// Hint suggests the next move deduced from the player-visible board, every flag is taken as a mine.
+// The board whose flags contradict the revealed counts has no hint, the wrong flag is not named.
+// Every hint is counted and makes the game not eligible for records
+func (g *Miner) Hint() (Hint, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.state != InProgress {
+		return Hint{}, ErrGameOver
+	}
+	var h Hint
+	if !g.placed {
+		// bombs are placed on the first reveal only when it is safe
+		h = Hint{X: g.width / 2, Y: g.height / 2, Reason: "the first reveal is always safe"}
+	} else {
+		board := solver.NewBoard(g.width, g.height)
+		for x, column := range g.grid.cells {
+			for y, cell := range column {
+				switch cell.state {
+				case Revealed:
+					board.Cells[g.grid.index(x, y)] = cell.count
+				case Flagged:
+					board.Cells[g.grid.index(x, y)] = solver.Flagged
+				}
+			}
+		}
+		if !consistent(board, g.bombsCount) {
+			return Hint{}, ErrNoHint
+		}
+		hint, ok := solver.Explain(board, g.bombsCount)
+		if !ok {
+			return Hint{}, ErrNoHint
+		}
+		h = Hint{X: hint.Cell.X, Y: hint.Cell.Y, Mine: hint.Mine, Guess: hint.Guess, Probability: hint.Probability, Reason: hint.Reason}
+		for _, cell := range hint.Because {
+			h.Because = append(h.Because, g.grid.getCell(cell.X, cell.Y).visible())
+		}
+	}
+	g.record(ActionHint, h.X, h.Y)
+	g.counters.Hints++
+	g.assisted = true
+	return h, nil
+}
+
+// consistent reports whether the flags fit the mine count and every revealed count has room for its mines
+// among the flagged and unknown cells around it
+func consistent(board solver.Board, mines int) bool {
+	flags := 0
+	var buf []int
+	for i, count := range board.Cells {
+		switch {
+		case count == solver.Flagged:
+			flags++
+		case count >= 0:
+			flagged, unknown := 0, 0
+			buf = board.Neighbours(i, buf[:0])
+			for _, j := range buf {
+				switch board.Cells[j] {
+				case solver.Flagged:
+					flagged++
+				case solver.Unknown:
+					unknown++
+				}
+			}
+			if flagged > count || flagged+unknown < count {
+				return false
+			}
+		}
+	}
+	return flags <= mines
+}
@@ -0,0 +1,156 @@
+package game
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+)
+
+var ErrNothingToUndo = errors.New("nothing to undo")
+var ErrNothingToRedo = errors.New("nothing to redo")
+
+// change is the state transition of the single cell
+type change struct {
+	index    int
+	from, to CellState
+}
+
+// progress is the part of the game outside of the cell states changed by the action
+type progress struct {
+	grid   *Grid
+	bombs  map[int]Position
+	placed bool
+	state  GameState
+	ended  time.Time
+}
+
+// action is the single player move in the history: the cell changes and the progress before and after it.
+// The hidden cells revealed by the move are kept apart as indexes, the flood of the large board reveals most of it
+type action struct {
+	changes       []change
+	revealed      []int32
+	before, after progress
+}
+
+func (g *Miner) progress() progress {
+	return progress{
+		grid:   g.grid,
+		bombs:  g.bombs,
+		placed: g.placed,
+		state:  g.state,
+		ended:  g.ended,
+	}
+}
+
+func (g *Miner) restore(p progress) {
+	if !p.placed && g.placed {
+		g.rand = rand.New(rand.NewSource(g.options.seed))
+	}
+	g.grid, g.bombs, g.placed = p.grid, p.bombs, p.placed
+	g.state, g.ended = p.state, p.ended
+}
+
+// begin starts recording the cell changes of the player move
+func (g *Miner) begin() {
+	g.action = &action{before: g.progress()}
+}
+
+// commit finishes recording and pushes the move to the undo history if it changed anything. Redo history is dropped
+func (g *Miner) commit() {
+	a := g.action
+	g.action = nil
+	a.after = g.progress()
+	if len(a.changes) == 0 && len(a.revealed) == 0 && a.before.grid == a.after.grid {
+		return
+	}
+	g.undo = append(g.undo, a)
+	g.redo = nil
+}
+
+// set changes the cell state, keeps the grid counters and records the change into the current move
+func (g *Miner) set(p Position, state CellState) {
+	cell := &g.grid.cells[p.x][p.y]
+	if cell.state == state {
+		return
+	}
+	switch {
+	case g.action == nil:
+	case cell.state == Hidden && state == Revealed:
+		g.action.revealed = append(g.action.revealed, int32(g.grid.index(p.x, p.y)))
+	default:
+		g.action.changes = append(g.action.changes, change{index: g.grid.index(p.x, p.y), from: cell.state, to: state})
+	}
+	g.grid.count(cell.state, -1)
+	g.grid.count(state, 1)
+	cell.state = state
+}
+
+// Undo reverts the last move. The move that ended the game can be undone only in practice games.
+// Returns the cells changed back and the game state. The game is not eligible for records anymore
+func (g *Miner) Undo() ([]Cell, GameState, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.undo) == 0 {
+		return nil, g.state, ErrNothingToUndo
+	}
+	if g.state != InProgress && !g.options.practice {
+		return nil, g.state, ErrGameOver
+	}
+	g.record(ActionUndo, 0, 0)
+	a := g.undo[len(g.undo)-1]
+	g.undo = g.undo[:len(g.undo)-1]
+	cells := make([]Cell, 0, len(a.changes)+len(a.revealed))
+	// the revealed cells are not changed again by the same move, so they are hidden after the other changes are reverted
+	for i := len(a.changes) - 1; i >= 0; i-- {
+		c := a.changes[i]
+		g.set(g.grid.position(c.index), c.from)
+	}
+	for _, i := range a.revealed {
+		g.set(g.grid.position(int(i)), Hidden)
+	}
+	g.restore(a.before)
+	for _, i := range a.revealed {
+		p := g.grid.position(int(i))
+		cells = append(cells, g.grid.getCell(p.x, p.y))
+	}
+	for _, c := range a.changes {
+		p := g.grid.position(c.index)
+		cells = append(cells, g.grid.getCell(p.x, p.y))
+	}
+	g.redo = append(g.redo, a)
+	g.assisted = true
+	return cells, g.state, nil
+}
+
+// Redo repeats the last undone move. Returns the cells changed again and the game state
+func (g *Miner) Redo() ([]Cell, GameState, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.redo) == 0 {
+		return nil, g.state, ErrNothingToRedo
+	}
+	g.record(ActionRedo, 0, 0)
+	a := g.redo[len(g.redo)-1]
+	g.redo = g.redo[:len(g.redo)-1]
+	g.restore(a.after)
+	cells := make([]Cell, 0, len(a.changes)+len(a.revealed))
+	for _, i := range a.revealed {
+		p := g.grid.position(int(i))
+		g.set(p, Revealed)
+		cells = append(cells, g.grid.getCell(p.x, p.y))
+	}
+	for _, c := range a.changes {
+		p := g.grid.position(c.index)
+		g.set(p, c.to)
+		cells = append(cells, g.grid.getCell(p.x, p.y))
+	}
+	g.undo = append(g.undo, a)
+	return cells, g.state, nil
+}
+
+// Eligible reports whether the game can be recorded in the high-score table: it is not a practice game, no move was undone and no hint was asked
+func (g *Miner) Eligible() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return !g.assisted
+}
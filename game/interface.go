@@ -1,8 +1,71 @@
 package game
 
+import (
+	"io"
+	"time"
+
+	"github.com/miner/analysis"
+)
+
 type Game interface {
 	Reveal(x, y int) ([]Cell, GameState, error)
-	Start(size, difficulty int) error
+	Chord(x, y int) ([]Cell, GameState, error)
+	Start(size, difficulty int, opts ...Option) error
+	StartRect(width, height, difficulty int, opts ...Option) error
+	Flag(x, y int) error
+	Unflag(x, y int) error
+	Question(x, y int) error
+	Flagged(x, y int) bool
+	Cell(x, y int) (Cell, error)
+	Remaining() int
+	Mines() int
+	BBBV() int
+	Stats() analysis.Stats
+	Seed() int64
+	State() GameState
+	Moves() int
+	Counters() Counters
+	StartedAt() time.Time
+	EndedAt() time.Time
+	Elapsed() time.Duration
+	Board() [][]Cell
+	Snapshot() Snapshot
+	Undo() ([]Cell, GameState, error)
+	Redo() ([]Cell, GameState, error)
+	Eligible() bool
+	Hint() (Hint, error)
+	Replay() Replay
+	Save(w io.Writer) error
+	Load(r io.Reader, opts ...Option) error
+}
+
+// Snapshot is the read-only player-visible view of the game
+type Snapshot struct {
+	Width     int
+	Height    int
+	Cells     [][]Cell
+	State     GameState
+	Remaining int
+	Elapsed   time.Duration
+	Moves     int
+	Counters  Counters
+}
+
+// Counters is the number of accepted player actions by kind
+type Counters struct {
+	// LeftClicks is the number of reveals
+	LeftClicks int
+	// RightClicks is the number of flag and question marks
+	RightClicks int
+	// Chords is the number of chords on revealed cells, including the ones that revealed nothing
+	Chords int
+	// Hints is the number of asked hints, they are not player actions and are not in the total
+	Hints int
+}
+
+// Total returns the number of all accepted player actions
+func (c Counters) Total() int {
+	return c.LeftClicks + c.RightClicks + c.Chords
 }
 
 type GameState string
@@ -12,3 +75,43 @@ const (
 	Win        GameState = "win"
 	InProgress GameState = "in progress"
 )
+
+// CellState is the state of the cell as the player sees it
+type CellState int
+
+const (
+	// Hidden cell is not revealed and not marked
+	Hidden CellState = iota
+	// Revealed cell shows its bomb count
+	Revealed
+	// Flagged cell is marked by the player as a bomb
+	Flagged
+	// Question cell is marked by the player as uncertain
+	Question
+	// Exploded cell is the bomb that ended the game
+	Exploded
+	// Mine cell is an unflagged bomb shown after the game is lost
+	Mine
+	// WrongFlag cell is a flagged cell without a bomb shown after the game is lost
+	WrongFlag
+)
+
+func (s CellState) String() string {
+	switch s {
+	case Hidden:
+		return "hidden"
+	case Revealed:
+		return "revealed"
+	case Flagged:
+		return "flagged"
+	case Question:
+		return "question"
+	case Exploded:
+		return "exploded"
+	case Mine:
+		return "mine"
+	case WrongFlag:
+		return "wrong flag"
+	}
+	return "unknown"
+}
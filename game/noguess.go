@@ -0,0 +1,70 @@
+package game
+
+import (
+	"errors"
+	"time"
+
+	"github.com/miner/analysis"
+	"github.com/miner/solver"
+)
+
+// maxRepairs is the number of times one random layout is repaired before it is replaced with a new one
+const maxRepairs = 50
+
+var ErrGenerationBudget = errors.New("no-guess board not found within the generation budget")
+
+// placeNoGuessBombs searches the layout the solver clears from the start cell without guessing. A stuck layout is repaired
+// by moving a mine from the unsolved frontier to a cell away from it, a layout that cannot be repaired is replaced with a new one.
+// Every checked layout counts as an attempt of the budget
+func (g *Miner) placeNoGuessBombs(start int, excluded map[int]Position) (map[int]Position, error) {
+	deadline := time.Now().Add(g.options.timeout)
+	var bombs map[int]Position
+	repairs := 0
+	for attempt := 0; attempt < g.options.attempts && time.Now().Before(deadline); attempt++ {
+		if bombs == nil {
+			bombs, repairs = g.placeBombs(excluded), 0
+		}
+		board, solved := analysis.Solve(g.layout(bombs), start)
+		if solved {
+			return bombs, nil
+		}
+		repairs++
+		if repairs > maxRepairs || !g.repair(bombs, board) {
+			bombs = nil
+		}
+	}
+	return nil, ErrGenerationBudget
+}
+
+// repair moves a random mine of the frontier, the unknown cells next to the revealed ones, to a random unknown cell off the frontier.
+// Returns false if there is no such mine or cell
+func (g *Miner) repair(bombs map[int]Position, board solver.Board) bool {
+	var frontier, free []int
+	near := make([]int, 0, 8)
+	for i, value := range board.Cells {
+		if value != solver.Unknown {
+			continue
+		}
+		edge := false
+		for _, n := range board.Neighbours(i, near[:0]) {
+			if board.Cells[n] >= 0 {
+				edge = true
+				break
+			}
+		}
+		_, bomb := bombs[i]
+		switch {
+		case edge && bomb:
+			frontier = append(frontier, i)
+		case !edge && !bomb:
+			free = append(free, i)
+		}
+	}
+	if len(frontier) == 0 || len(free) == 0 {
+		return false
+	}
+	from, to := frontier[g.rand.Intn(len(frontier))], free[g.rand.Intn(len(free))]
+	delete(bombs, from)
+	bombs[to] = Position{to / g.height, to % g.height}
+	return true
+}
@@ -0,0 +1,117 @@
+package game
+
+import "time"
+
+// default budget of the no-guess board generation
+const (
+	defaultGenerationAttempts = 1000
+	defaultGenerationTimeout  = 5 * time.Second
+)
+
+// FirstClick defines which cells are guaranteed to be free of bombs on the first reveal
+type FirstClick int
+
+const (
+	// FirstClickAny places bombs on Start, the first reveal can hit a bomb
+	FirstClickAny FirstClick = iota
+	// FirstClickSafe places bombs on the first reveal, the revealed cell never has a bomb
+	FirstClickSafe
+	// FirstClickOpening places bombs on the first reveal, the revealed cell and its neighbours never have a bomb.
+	// Falls back to FirstClickSafe if the board is too dense to keep the neighbours free
+	FirstClickOpening
+)
+
+type Option func(*options)
+
+type options struct {
+	firstClick FirstClick
+	seed       int64
+	seeded     bool
+	mines      int
+	now        func() time.Time
+	practice   bool
+	noGuess    bool
+	attempts   int
+	timeout    time.Duration
+	debug      bool
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		firstClick: FirstClickAny,
+		now:        time.Now,
+		attempts:   defaultGenerationAttempts,
+		timeout:    defaultGenerationTimeout,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithFirstClick sets the first click safety mode. Default is FirstClickAny
+func WithFirstClick(mode FirstClick) Option {
+	return func(o *options) {
+		o.firstClick = mode
+	}
+}
+
+// WithSeed sets the seed of the bomb layout. The same size, difficulty, seed and first click always give the same layout.
+// Default is a seed based on the current time
+func WithSeed(seed int64) Option {
+	return func(o *options) {
+		o.seed = seed
+		o.seeded = true
+	}
+}
+
+// WithMines sets the exact number of bombs instead of the difficulty percentage. Must be less than the number of cells
+func WithMines(mines int) Option {
+	return func(o *options) {
+		o.mines = mines
+	}
+}
+
+// WithClock sets the clock used by the game timer. Default is time.Now
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+// WithPractice allows to undo the move that lost or won the game. Practice games are not eligible for records
+func WithPractice() Option {
+	return func(o *options) {
+		o.practice = true
+	}
+}
+
+// WithNoGuess places bombs on the first reveal so that the whole board can be cleared by logic from the revealed cell,
+// the cell and its neighbours are kept free as with FirstClickOpening. The first reveal returns ErrGenerationBudget
+// if no such layout is found within the generation budget
+func WithNoGuess() Option {
+	return func(o *options) {
+		o.noGuess = true
+	}
+}
+
+// WithGenerationBudget limits the no-guess generation by the number of checked layouts and by the time.
+// Zero keeps the default of 1000 layouts and 5 seconds
+func WithGenerationBudget(attempts int, timeout time.Duration) Option {
+	return func(o *options) {
+		if attempts > 0 {
+			o.attempts = attempts
+		}
+		if timeout > 0 {
+			o.timeout = timeout
+		}
+	}
+}
+
+// WithDebug allows reading the bomb layout with Layout, for the tools and the tests. The games played by the clients
+// must not be started with it, or the layout can be read
+func WithDebug() Option {
+	return func(o *options) {
+		o.debug = true
+	}
+}
@@ -0,0 +1,204 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+const replayVersion = 1
+
+var ErrReplayVersion = errors.New("unsupported replay version")
+var ErrCorruptReplay = errors.New("corrupt replay")
+
+// Action is the kind of the recorded player move
+type Action string
+
+const (
+	ActionReveal   Action = "reveal"
+	ActionChord    Action = "chord"
+	ActionFlag     Action = "flag"
+	ActionQuestion Action = "question"
+	ActionUnflag   Action = "unflag"
+	ActionUndo     Action = "undo"
+	ActionRedo     Action = "redo"
+	ActionHint     Action = "hint"
+)
+
+// Step is the single accepted player move. At is the time since the game was started
+type Step struct {
+	Action Action        `json:"action"`
+	X      int           `json:"x"`
+	Y      int           `json:"y"`
+	At     time.Duration `json:"at"`
+}
+
+// Replay is the board settings and every move of the game. The bomb layout is reproduced from the seed and the first click
+type Replay struct {
+	Version    int        `json:"version"`
+	Width      int        `json:"width"`
+	Height     int        `json:"height"`
+	Difficulty int        `json:"difficulty"`
+	Mines      int        `json:"mines"`
+	Seed       int64      `json:"seed"`
+	FirstClick FirstClick `json:"first_click"`
+	Practice   bool       `json:"practice"`
+	NoGuess    bool       `json:"no_guess"`
+	Steps      []Step     `json:"-"`
+}
+
+// record appends the accepted move to the replay
+func (g *Miner) record(action Action, x, y int) {
+	g.steps = append(g.steps, Step{Action: action, X: x, Y: y, At: g.options.now().Sub(g.opened)})
+}
+
+// Replay returns the recording of the game from its start. The seed is zero while the game is played, as with Seed
+func (g *Miner) Replay() Replay {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	r := Replay{
+		Version:    replayVersion,
+		Width:      g.width,
+		Height:     g.height,
+		Difficulty: g.difficulty,
+		Mines:      g.bombsCount,
+		Seed:       g.options.seed,
+		FirstClick: g.options.firstClick,
+		Practice:   g.options.practice,
+		NoGuess:    g.options.noGuess,
+		Steps:      append([]Step(nil), g.steps...),
+	}
+	if !g.disclosed() {
+		r.Seed = 0
+	}
+	return r
+}
+
+// Write encodes the replay as JSON lines: the board settings first, then one line per step
+func (r Replay) Write(w io.Writer) error {
+	r.Version = replayVersion
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(r); err != nil {
+		return err
+	}
+	for _, s := range r.Steps {
+		if err := enc.Encode(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadReplay decodes the replay written by Replay.Write
+func ReadReplay(r io.Reader) (Replay, error) {
+	var replay Replay
+	dec := json.NewDecoder(r)
+	if err := dec.Decode(&replay); err != nil {
+		return Replay{}, fmt.Errorf("%w: %v", ErrCorruptReplay, err)
+	}
+	if replay.Version != replayVersion {
+		return Replay{}, ErrReplayVersion
+	}
+	if replay.Width <= 0 || replay.Height <= 0 || replay.Mines < 0 || replay.Mines >= replay.Width*replay.Height {
+		return Replay{}, ErrCorruptReplay
+	}
+	for {
+		var s Step
+		err := dec.Decode(&s)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Replay{}, fmt.Errorf("%w: %v", ErrCorruptReplay, err)
+		}
+		replay.Steps = append(replay.Steps, s)
+	}
+	if !validSteps(replay.Steps, replay.Width, replay.Height) {
+		return Replay{}, ErrCorruptReplay
+	}
+	return replay, nil
+}
+
+// validSteps checks the actions, positions and the order of the step times
+func validSteps(steps []Step, width, height int) bool {
+	var at time.Duration
+	for _, s := range steps {
+		switch s.Action {
+		case ActionReveal, ActionChord, ActionFlag, ActionQuestion, ActionUnflag, ActionHint:
+			if s.X < 0 || s.X >= width || s.Y < 0 || s.Y >= height {
+				return false
+			}
+		case ActionUndo, ActionRedo:
+		default:
+			return false
+		}
+		if s.At < at {
+			return false
+		}
+		at = s.At
+	}
+	return true
+}
+
+// Start starts the game with the board settings of the replay. Options such as the clock are taken from opts
+func (r Replay) Start(g Game, opts ...Option) error {
+	opts = append(opts, WithSeed(r.Seed), WithFirstClick(r.FirstClick))
+	if r.Mines > 0 {
+		opts = append(opts, WithMines(r.Mines))
+	}
+	if r.Practice {
+		opts = append(opts, WithPractice())
+	}
+	if r.NoGuess {
+		opts = append(opts, WithNoGuess())
+	}
+	return g.StartRect(r.Width, r.Height, r.Difficulty, opts...)
+}
+
+// Play starts the game and applies every step of the replay
+func (r Replay) Play(g Game, opts ...Option) error {
+	if err := r.Start(g, opts...); err != nil {
+		return err
+	}
+	for i, s := range r.Steps {
+		if _, _, err := s.Apply(g); err != nil {
+			return fmt.Errorf("step %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Apply makes the move of the step in the game. Returns the changed cells and the game state
+func (s Step) Apply(g Game) ([]Cell, GameState, error) {
+	var err error
+	switch s.Action {
+	case ActionReveal:
+		return g.Reveal(s.X, s.Y)
+	case ActionChord:
+		return g.Chord(s.X, s.Y)
+	case ActionUndo:
+		return g.Undo()
+	case ActionRedo:
+		return g.Redo()
+	case ActionFlag:
+		err = g.Flag(s.X, s.Y)
+	case ActionQuestion:
+		err = g.Question(s.X, s.Y)
+	case ActionUnflag:
+		err = g.Unflag(s.X, s.Y)
+	case ActionHint:
+		_, err = g.Hint()
+	default:
+		return nil, g.State(), ErrCorruptReplay
+	}
+	if err != nil {
+		return nil, g.State(), err
+	}
+	cell, err := g.Cell(s.X, s.Y)
+	if err != nil {
+		return nil, g.State(), err
+	}
+	return []Cell{cell}, g.State(), nil
+}
@@ -0,0 +1,209 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"time"
+)
+
+const saveVersion = 1
+
+var ErrSaveVersion = errors.New("unsupported save version")
+var ErrCorruptSave = errors.New("corrupt save")
+var ErrNotStarted = errors.New("game is not started")
+
+// save is the versioned on-disk format of the game. Cells are numbered column by column
+type save struct {
+	Version    int           `json:"version"`
+	Width      int           `json:"width"`
+	Height     int           `json:"height"`
+	Difficulty int           `json:"difficulty"`
+	Mines      int           `json:"mines"`
+	Seed       int64         `json:"seed"`
+	FirstClick FirstClick    `json:"first_click"`
+	Placed     bool          `json:"placed"`
+	Bombs      []int         `json:"bombs"`
+	Cells      []CellState   `json:"cells"`
+	State      GameState     `json:"state"`
+	Started    bool          `json:"started"`
+	Elapsed    time.Duration `json:"elapsed"`
+	Counters   Counters      `json:"counters"`
+	Practice   bool          `json:"practice"`
+	NoGuess    bool          `json:"no_guess"`
+	Assisted   bool          `json:"assisted"`
+	Steps      []Step        `json:"steps"`
+}
+
+// Save writes the game with its mine layout, marks, elapsed time, seed and the recorded moves.
+// The save restores the running game, so it holds the layout: it is kept by the front-end of the player, never sent to others.
+// Returns ErrNotStarted before the game is started
+func (g *Miner) Save(w io.Writer) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.grid == nil {
+		return ErrNotStarted
+	}
+	s := save{
+		Version:    saveVersion,
+		Width:      g.width,
+		Height:     g.height,
+		Difficulty: g.difficulty,
+		Mines:      g.bombsCount,
+		Seed:       g.options.seed,
+		FirstClick: g.options.firstClick,
+		Placed:     g.placed,
+		Bombs:      make([]int, 0, len(g.bombs)),
+		Cells:      make([]CellState, 0, g.width*g.height),
+		State:      g.state,
+		Started:    !g.started.IsZero(),
+		Elapsed:    g.elapsed(),
+		Counters:   g.counters,
+		Practice:   g.options.practice,
+		NoGuess:    g.options.noGuess,
+		Assisted:   g.assisted,
+		Steps:      g.steps,
+	}
+	for x := range g.grid.cells {
+		for y, cell := range g.grid.cells[x] {
+			if cell.bomb {
+				s.Bombs = append(s.Bombs, g.grid.index(x, y))
+			}
+			s.Cells = append(s.Cells, cell.state)
+		}
+	}
+	return json.NewEncoder(w).Encode(s)
+}
+
+// Load replaces the game with the saved one. The timer continues from the saved elapsed time, the replay continues from the saved steps, the undo history starts empty.
+// Options not stored in the save, such as the clock, are taken from opts
+func (g *Miner) Load(r io.Reader, opts ...Option) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var s save
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return fmt.Errorf("%w: %v", ErrCorruptSave, err)
+	}
+	if s.Version != saveVersion {
+		return ErrSaveVersion
+	}
+	if err := s.validate(); err != nil {
+		return err
+	}
+	opts = append(opts, WithSeed(s.Seed), WithFirstClick(s.FirstClick))
+	if s.Practice {
+		opts = append(opts, WithPractice())
+	}
+	if s.NoGuess {
+		opts = append(opts, WithNoGuess())
+	}
+	o := newOptions(opts)
+	bombs := make(map[int]Position, len(s.Bombs))
+	for _, b := range s.Bombs {
+		bombs[b] = Position{b / s.Height, b % s.Height}
+	}
+	grid := newGrid(s.Width, s.Height, bombs)
+	for i, state := range s.Cells {
+		p := grid.position(i)
+		grid.cells[p.x][p.y].state = state
+		grid.count(state, 1)
+	}
+
+	g.options = o
+	g.width, g.height, g.difficulty = s.Width, s.Height, s.Difficulty
+	g.bombsCount = s.Mines
+	g.bombs = bombs
+	g.grid = grid
+	g.placed = s.Placed
+	g.rand = rand.New(rand.NewSource(s.Seed))
+	g.state = s.State
+	g.counters = s.Counters
+	g.assisted = s.Assisted
+	g.undo, g.redo = nil, nil
+	g.steps = append([]Step(nil), s.Steps...)
+	now := o.now()
+	g.opened = now
+	if len(s.Steps) > 0 {
+		g.opened = now.Add(-s.Steps[len(s.Steps)-1].At)
+	}
+	g.started, g.ended = time.Time{}, time.Time{}
+	if s.Started {
+		g.started = now.Add(-s.Elapsed)
+		if s.State != InProgress {
+			g.ended = now
+		}
+	}
+	return nil
+}
+
+func (s save) validate() error {
+	cells := s.Width * s.Height
+	if s.Width <= 0 || s.Height <= 0 || len(s.Cells) != cells {
+		return ErrCorruptSave
+	}
+	if s.Mines < 0 || s.Mines > cells || s.Elapsed < 0 {
+		return ErrCorruptSave
+	}
+	if !validSteps(s.Steps, s.Width, s.Height) {
+		return ErrCorruptSave
+	}
+	if s.Placed && len(s.Bombs) != s.Mines || !s.Placed && len(s.Bombs) != 0 {
+		return ErrCorruptSave
+	}
+	seen := make(map[int]bool, len(s.Bombs))
+	for _, b := range s.Bombs {
+		if b < 0 || b >= cells || seen[b] {
+			return ErrCorruptSave
+		}
+		seen[b] = true
+	}
+	switch s.State {
+	case InProgress, Win, Lose:
+	default:
+		return ErrCorruptSave
+	}
+	return s.validateCells(seen)
+}
+
+// validateCells cross-checks the cell states with the bombs and the game state: the bombs are shown only after the game is lost,
+// a lost game has the exploded bomb and a won one has every safe cell revealed
+func (s save) validateCells(bombs map[int]bool) error {
+	revealed, exploded := 0, 0
+	for i, state := range s.Cells {
+		switch state {
+		case Hidden, Flagged, Question:
+		case Revealed:
+			if bombs[i] {
+				return ErrCorruptSave
+			}
+			revealed++
+		case Exploded, Mine:
+			if !bombs[i] || s.State != Lose {
+				return ErrCorruptSave
+			}
+			if state == Exploded {
+				exploded++
+			}
+		case WrongFlag:
+			if bombs[i] || s.State != Lose {
+				return ErrCorruptSave
+			}
+		default:
+			return ErrCorruptSave
+		}
+	}
+	safe := len(s.Cells) - s.Mines
+	switch {
+	case !s.Placed && revealed > 0:
+		return ErrCorruptSave
+	case s.State == Lose && exploded == 0:
+		return ErrCorruptSave
+	case s.State == Win && revealed != safe:
+		return ErrCorruptSave
+	case s.State == InProgress && revealed == safe && s.Placed:
+		return ErrCorruptSave
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+// Package sysuser names the local player after the system user
+package sysuser
+
+import "os/user"
+
+// Name returns the name of the system user, the won games are recorded and the multiplayer rooms joined with it
+func Name() string {
+	u, err := user.Current()
+	if err != nil || u.Username == "" {
+		return "player"
+	}
+	return u.Username
+}
@@ -54,3 +54,13 @@ func (l *Log) Error(component string, format string, a ...any) {
 func (l *Log) Fatal(component string, format string, a ...any) {
 	log.Fatal().Msgf(fmt.Sprintf("| %-6s |%s", component, format), a...)
 }
+
+// SetLevel sets the minimal level of the printed messages: debug, info, warn, error or fatal
+func SetLevel(level string) error {
+	l, err := zerolog.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	zerolog.SetGlobalLevel(l)
+	return nil
+}
@@ -1,14 +1,20 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"os"
+
+	"github.com/miner/cli"
 	"github.com/miner/logger"
-	"github.com/miner/ui"
 )
 
 func main() {
 	log := logger.NewLog()
-	client := ui.NewClient(log)
-	err := client.Run()
+	err := cli.New(log, os.Stdout).Run(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
 	if err != nil {
 		log.Fatal("client", "Run : %v", err)
 	}
@@ -0,0 +1,246 @@
+package scores
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	version      = 1
+	defaultLimit = 10
+	fileName     = "scores.json"
+	lockTimeout  = 2 * time.Second
+	lockStale    = 10 * time.Second
+)
+
+var ErrLocked = errors.New("scores file is locked")
+var ErrNewerVersion = errors.New("scores file is written by a newer version")
+
+// Board is the configuration the best times are tracked for
+type Board struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	Mines  int `json:"mines"`
+}
+
+func (b Board) String() string {
+	return fmt.Sprintf("%dx%dx%d", b.Width, b.Height, b.Mines)
+}
+
+// Entry is the single won game in the high-score table
+type Entry struct {
+	Name string        `json:"name"`
+	Time time.Duration `json:"time"`
+	Date time.Time     `json:"date"`
+	BBBV int           `json:"3bv"`
+	Seed int64         `json:"seed"`
+}
+
+type table struct {
+	Version int                `json:"version"`
+	Boards  map[string][]Entry `json:"boards"`
+	Configs map[string]Board   `json:"configs"`
+}
+
+func newTable() *table {
+	return &table{
+		Version: version,
+		Boards:  make(map[string][]Entry),
+		Configs: make(map[string]Board),
+	}
+}
+
+// Store keeps the best times per board in the JSON file.
+// Reads and writes are serialized in the process with a mutex and between processes with a lock file, the file is replaced atomically.
+// Reads take the lock too, as the one moving a corrupt file aside must not race with the writer replacing it.
+// A file that does not parse is moved aside with the .corrupt suffix and the table starts empty, a newer file is left as is
+// and returns ErrNewerVersion
+type Store struct {
+	path  string
+	limit int
+	mu    sync.Mutex
+}
+
+func NewStore(path string) *Store {
+	return &Store{
+		path:  path,
+		limit: defaultLimit,
+	}
+}
+
+// DefaultPath returns the scores file path in the user config dir
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "miner", fileName), nil
+}
+
+// Add records the entry and returns its 0-based rank, -1 if the time is not good enough for the table
+func (s *Store) Add(board Board, entry Entry) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.lock()
+	if err != nil {
+		return -1, err
+	}
+	defer unlock()
+
+	t, err := s.load()
+	if err != nil {
+		return -1, err
+	}
+	key := board.String()
+	entries := t.Boards[key]
+	rank := sort.Search(len(entries), func(i int) bool {
+		return entries[i].Time > entry.Time
+	})
+	if rank >= s.limit {
+		return -1, nil
+	}
+	entries = append(entries, Entry{})
+	copy(entries[rank+1:], entries[rank:])
+	entries[rank] = entry
+	if len(entries) > s.limit {
+		entries = entries[:s.limit]
+	}
+	t.Boards[key] = entries
+	t.Configs[key] = board
+	return rank, s.save(t)
+}
+
+// Top returns the best times of the board, fastest first
+func (s *Store) Top(board Board) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	t, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	return t.Boards[board.String()], nil
+}
+
+// Boards returns all boards with recorded times ordered by the number of cells and mines
+func (s *Store) Boards() ([]Board, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	t, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	boards := make([]Board, 0, len(t.Configs))
+	for _, b := range t.Configs {
+		boards = append(boards, b)
+	}
+	sort.Slice(boards, func(i, j int) bool {
+		ci, cj := boards[i].Width*boards[i].Height, boards[j].Width*boards[j].Height
+		if ci != cj {
+			return ci < cj
+		}
+		if boards[i].Mines != boards[j].Mines {
+			return boards[i].Mines < boards[j].Mines
+		}
+		return boards[i].Width < boards[j].Width
+	})
+	return boards, nil
+}
+
+// load reads the table, a missing file is an empty table, a file that does not parse is moved aside
+func (s *Store) load() (*table, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return newTable(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	t := newTable()
+	if err := json.Unmarshal(data, t); err != nil {
+		if err := os.Rename(s.path, s.path+".corrupt"); err != nil {
+			return nil, err
+		}
+		return newTable(), nil
+	}
+	if t.Version > version {
+		return nil, ErrNewerVersion
+	}
+	t.Version = version
+	if t.Boards == nil {
+		t.Boards = make(map[string][]Entry)
+	}
+	if t.Configs == nil {
+		t.Configs = make(map[string]Board)
+	}
+	return t, nil
+}
+
+// save writes the table to the temporary file and renames it over the scores file
+func (s *Store) save(t *table) error {
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), fileName+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// lock creates the lock file next to the scores file. Lock files older than lockStale are left by crashed processes and removed
+func (s *Store) lock() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return nil, err
+	}
+	path := s.path + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > lockStale {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, ErrLocked
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,142 @@
+package scores
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+var expert = Board{Width: 30, Height: 16, Mines: 99}
+
+func TestStore_Add(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "miner", fileName))
+	store.limit = 3
+	tests := []struct {
+		time         time.Duration
+		expectedRank int
+	}{
+		{time: 30 * time.Second, expectedRank: 0},
+		{time: 10 * time.Second, expectedRank: 0},
+		{time: 20 * time.Second, expectedRank: 1},
+		{time: 40 * time.Second, expectedRank: -1},
+		{time: 15 * time.Second, expectedRank: 1},
+	}
+	for _, tc := range tests {
+		rank, err := store.Add(expert, Entry{Name: "player", Time: tc.time})
+		if err != nil {
+			t.Fatalf("expected: %v, got: %v", nil, err)
+		}
+		if rank != tc.expectedRank {
+			t.Fatalf("expected: %v, got: %v", tc.expectedRank, rank)
+		}
+	}
+	top, err := store.Top(expert)
+	if err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	expected := []time.Duration{10 * time.Second, 15 * time.Second, 20 * time.Second}
+	if len(top) != len(expected) {
+		t.Fatalf("expected: %v, got: %v", len(expected), len(top))
+	}
+	for i := range expected {
+		if top[i].Time != expected[i] {
+			t.Fatalf("expected: %v, got: %v", expected[i], top[i].Time)
+		}
+	}
+	if top, _ := store.Top(Board{Width: 9, Height: 9, Mines: 10}); len(top) != 0 {
+		t.Fatalf("expected: %v, got: %v", 0, len(top))
+	}
+	boards, err := store.Boards()
+	if err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if len(boards) != 1 || boards[0] != expert {
+		t.Fatalf("expected: %v, got: %v", []Board{expert}, boards)
+	}
+}
+
+func TestStore_Concurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), fileName)
+	stores := []*Store{NewStore(path), NewStore(path)}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := stores[i%2].Add(expert, Entry{Name: "player", Time: time.Duration(i+1) * time.Second}); err != nil {
+				t.Errorf("expected: %v, got: %v", nil, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	top, err := NewStore(path).Top(expert)
+	if err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if len(top) != defaultLimit {
+		t.Fatalf("expected: %v, got: %v", defaultLimit, len(top))
+	}
+	for i, entry := range top {
+		if entry.Time != time.Duration(i+1)*time.Second {
+			t.Fatalf("expected: %v, got: %v", time.Duration(i+1)*time.Second, entry.Time)
+		}
+	}
+}
+
+func TestStore_Corrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), fileName)
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store := NewStore(path)
+	if top, err := store.Top(expert); err != nil || len(top) != 0 {
+		t.Fatalf("expected empty table, got: %v %v", top, err)
+	}
+	if _, err := store.Add(expert, Entry{Name: "player", Time: time.Second}); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	if top, err := store.Top(expert); err != nil || len(top) != 1 {
+		t.Fatalf("expected one entry, got: %v %v", top, err)
+	}
+	if _, err := os.Stat(path + ".corrupt"); err != nil {
+		t.Fatalf("expected the corrupt file to be kept, got: %v", err)
+	}
+}
+
+func TestStore_NewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), fileName)
+	data := []byte(`{"version": 2, "boards": {}}`)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store := NewStore(path)
+	if _, err := store.Top(expert); !errors.Is(err, ErrNewerVersion) {
+		t.Fatalf("expected: %v, got: %v", ErrNewerVersion, err)
+	}
+	if _, err := store.Add(expert, Entry{Name: "player", Time: time.Second}); !errors.Is(err, ErrNewerVersion) {
+		t.Fatalf("expected: %v, got: %v", ErrNewerVersion, err)
+	}
+	if kept, err := os.ReadFile(path); err != nil || string(kept) != string(data) {
+		t.Fatalf("expected the newer file to be left as is, got: %s %v", kept, err)
+	}
+	if _, err := os.Stat(path + ".corrupt"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected no corrupt file, got: %v", err)
+	}
+}
+
+func TestStore_StaleLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), fileName)
+	if err := os.WriteFile(path+".lock", nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * lockStale)
+	if err := os.Chtimes(path+".lock", old, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewStore(path).Add(expert, Entry{Name: "player", Time: time.Second}); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+}
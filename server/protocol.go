@@ -0,0 +1,164 @@
+package server
+
+import (
+	"errors"
+	"time"
+
+	"github.com/miner/game"
+)
+
+// Mode is how the players of the room share the board
+type Mode string
+
+const (
+	// ModeCoop is one board played together, the move of every player is applied to it
+	ModeCoop Mode = "coop"
+	// ModeRace gives every player the own board with the same layout opened in the centre, the first to clear it wins
+	ModeRace Mode = "race"
+)
+
+// types of the requests
+const (
+	TypeJoin  = "join"
+	TypeMove  = "move"
+	TypeLeave = "leave"
+)
+
+// types of the responses
+const (
+	TypeJoined  = "joined"
+	TypeState   = "state"
+	TypePlayers = "players"
+	TypeLeft    = "left"
+	TypeError   = "error"
+)
+
+var ErrInvalidJoin = errors.New("room and player are required")
+var ErrInvalidSettings = errors.New("invalid board settings")
+var ErrRoomMode = errors.New("room is played in another mode")
+var ErrNameTaken = errors.New("player name is taken in the room")
+var ErrUnknownToken = errors.New("unknown or expired session")
+var ErrAlreadyJoined = errors.New("already joined a room")
+var ErrNotJoined = errors.New("not joined to a room")
+var ErrInvalidMove = errors.New("invalid move")
+var ErrRaceOver = errors.New("race is over")
+var ErrUnknownRequest = errors.New("unknown request")
+
+// knownErrors are the errors restored from the error responses
+var knownErrors = []error{
+	ErrInvalidJoin, ErrInvalidSettings, ErrRoomMode, ErrNameTaken, ErrUnknownToken, ErrAlreadyJoined, ErrNotJoined,
+	ErrInvalidMove, ErrRaceOver, ErrUnknownRequest,
+	game.ErrInvalidPosition, game.ErrCellFlagged, game.ErrCellRevealed, game.ErrCellNotRevealed, game.ErrGameOver,
+}
+
+func errorOf(message string) error {
+	for _, err := range knownErrors {
+		if err.Error() == message {
+			return err
+		}
+	}
+	return errors.New(message)
+}
+
+// Request is the message of the client, one JSON object per line. Join creates the room with the board settings
+// if it does not exist yet, the token of the earlier join resumes the session after a lost connection
+type Request struct {
+	ID     int         `json:"id,omitempty"`
+	Type   string      `json:"type"`
+	Room   string      `json:"room,omitempty"`
+	Player string      `json:"player,omitempty"`
+	Token  string      `json:"token,omitempty"`
+	Mode   Mode        `json:"mode,omitempty"`
+	Width  int         `json:"width,omitempty"`
+	Height int         `json:"height,omitempty"`
+	Mines  int         `json:"mines,omitempty"`
+	Seed   int64       `json:"seed,omitempty"`
+	Action game.Action `json:"action,omitempty"`
+	X      int         `json:"x"`
+	Y      int         `json:"y"`
+}
+
+// Response is the message of the server, one JSON object per line. The answer to the request carries its ID,
+// the broadcasts caused by the other players have no ID. The seed is sent once the game or the race is over
+type Response struct {
+	ID      int            `json:"id,omitempty"`
+	Type    string         `json:"type"`
+	Room    string         `json:"room,omitempty"`
+	Mode    Mode           `json:"mode,omitempty"`
+	Seed    int64          `json:"seed,omitempty"`
+	Token   string         `json:"token,omitempty"`
+	Player  string         `json:"player,omitempty"`
+	Board   *Board         `json:"board,omitempty"`
+	Players []PlayerStatus `json:"players,omitempty"`
+	Winner  string         `json:"winner,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+// PlayerStatus is the progress of the player in the room
+type PlayerStatus struct {
+	Name      string         `json:"name"`
+	Connected bool           `json:"connected"`
+	State     game.GameState `json:"state"`
+	Revealed  int            `json:"revealed"`
+	Safe      int            `json:"safe"`
+}
+
+// Board is the player-visible board. Cells are numbered column by column, the 3BV is sent once the game is over
+type Board struct {
+	Width     int            `json:"width"`
+	Height    int            `json:"height"`
+	Mines     int            `json:"mines"`
+	Cells     []Cell         `json:"cells"`
+	State     game.GameState `json:"state"`
+	Remaining int            `json:"remaining"`
+	Started   bool           `json:"started"`
+	Elapsed   time.Duration  `json:"elapsed"`
+	Counters  game.Counters  `json:"counters"`
+	BBBV      int            `json:"bbbv,omitempty"`
+}
+
+// Cell is the state of the cell and its count if revealed
+type Cell struct {
+	State game.CellState `json:"s"`
+	Count int            `json:"c,omitempty"`
+}
+
+func newBoard(g game.Game) *Board {
+	s := g.Snapshot()
+	b := &Board{
+		Width:     s.Width,
+		Height:    s.Height,
+		Mines:     g.Mines(),
+		Cells:     make([]Cell, 0, s.Width*s.Height),
+		State:     s.State,
+		Remaining: s.Remaining,
+		Started:   !g.StartedAt().IsZero(),
+		Elapsed:   s.Elapsed,
+		Counters:  s.Counters,
+	}
+	for _, column := range s.Cells {
+		for _, cell := range column {
+			b.Cells = append(b.Cells, Cell{State: cell.State(), Count: cell.Count()})
+		}
+	}
+	if s.State != game.InProgress {
+		b.BBBV = g.BBBV()
+	}
+	return b
+}
+
+// revealed returns the number of the revealed cells
+func (b *Board) revealed() int {
+	n := 0
+	for _, cell := range b.Cells {
+		if cell.State == game.Revealed {
+			n++
+		}
+	}
+	return n
+}
+
+func (b *Board) cell(x, y int) game.Cell {
+	c := b.Cells[x*b.Height+y]
+	return game.NewCell(x, y, c.State, c.Count)
+}
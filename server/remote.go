@@ -0,0 +1,453 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/miner/analysis"
+	"github.com/miner/game"
+)
+
+const (
+	requestTimeout   = 10 * time.Second
+	reconnectRetries = 5
+	reconnectDelay   = 500 * time.Millisecond
+)
+
+var ErrNotSupported = errors.New("not supported in the multiplayer game")
+var ErrDisconnected = errors.New("disconnected from the server")
+
+// Join is the room to join and the board settings used if the room does not exist yet. Zero seed is random
+type Join struct {
+	Room   string
+	Player string
+	Mode   Mode
+	Width  int
+	Height int
+	Mines  int
+	Seed   int64
+}
+
+// Remote is the game played on the server. It implements game.Game for the moves and the player-visible board,
+// the settings, saves, undo and hints are not available. The lost connection is resumed in the background
+type Remote struct {
+	addr string
+
+	mu       sync.Mutex
+	conn     net.Conn
+	enc      *json.Encoder
+	token    string
+	room     string
+	mode     Mode
+	seed     int64
+	player   string
+	board    Board
+	received time.Time
+	players  []PlayerStatus
+	winner   string
+	nextID   int
+	pending  map[int]chan Response
+	closed   bool
+	changes  chan struct{}
+}
+
+// Dial connects to the server and joins the room
+func Dial(addr string, join Join) (*Remote, error) {
+	r := &Remote{
+		addr:    addr,
+		pending: make(map[int]chan Response),
+		changes: make(chan struct{}, 1),
+	}
+	req := Request{Type: TypeJoin, Room: join.Room, Player: join.Player, Mode: join.Mode,
+		Width: join.Width, Height: join.Height, Mines: join.Mines, Seed: join.Seed}
+	if err := r.connect(req); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// connect dials the server, sends the join request and starts reading. Returns the error of the join
+func (r *Remote) connect(req Request) error {
+	nc, err := net.DialTimeout("tcp", r.addr, requestTimeout)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(nc)
+	nc.SetDeadline(time.Now().Add(requestTimeout))
+	if err := enc.Encode(req); err != nil {
+		nc.Close()
+		return err
+	}
+	dec := json.NewDecoder(bufio.NewReader(nc))
+	var resp Response
+	if err := dec.Decode(&resp); err != nil {
+		nc.Close()
+		return err
+	}
+	nc.SetDeadline(time.Time{})
+	if resp.Type == TypeError {
+		nc.Close()
+		return errorOf(resp.Error)
+	}
+	r.mu.Lock()
+	r.conn, r.enc = nc, enc
+	r.token, r.room, r.mode, r.player = resp.Token, resp.Room, resp.Mode, resp.Player
+	r.apply(resp)
+	r.mu.Unlock()
+	go r.read(nc, dec)
+	return nil
+}
+
+// read receives the answers and the broadcasts until the connection is lost, then resumes the session
+func (r *Remote) read(nc net.Conn, dec *json.Decoder) {
+	for {
+		var resp Response
+		if err := dec.Decode(&resp); err != nil {
+			break
+		}
+		r.mu.Lock()
+		r.apply(resp)
+		ch, ok := r.pending[resp.ID]
+		delete(r.pending, resp.ID)
+		r.mu.Unlock()
+		if ok && resp.ID != 0 {
+			ch <- resp
+		}
+		r.changed()
+	}
+	nc.Close()
+	r.mu.Lock()
+	closed := r.closed
+	for id, ch := range r.pending {
+		close(ch)
+		delete(r.pending, id)
+	}
+	r.mu.Unlock()
+	if closed {
+		return
+	}
+	for i := 0; i < reconnectRetries; i++ {
+		time.Sleep(reconnectDelay << i)
+		r.mu.Lock()
+		token, closed := r.token, r.closed
+		r.mu.Unlock()
+		if closed {
+			return
+		}
+		err := r.connect(Request{Type: TypeJoin, Token: token})
+		if err == nil {
+			r.changed()
+			return
+		}
+		if errors.Is(err, ErrUnknownToken) {
+			break
+		}
+	}
+	r.mu.Lock()
+	r.closed = true
+	r.mu.Unlock()
+	r.changed()
+}
+
+// apply updates the board, the players and the seed from the response, the caller holds the lock
+func (r *Remote) apply(resp Response) {
+	if resp.Seed != 0 {
+		r.seed = resp.Seed
+	}
+	if resp.Board != nil && (resp.Player == r.player || r.mode == ModeCoop) {
+		r.board, r.received = *resp.Board, time.Now()
+	}
+	if resp.Players != nil {
+		r.players, r.winner = resp.Players, resp.Winner
+	}
+}
+
+func (r *Remote) changed() {
+	select {
+	case r.changes <- struct{}{}:
+	default:
+	}
+}
+
+// request sends the request and waits for its answer
+func (r *Remote) request(req Request) (Response, error) {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return Response{}, ErrDisconnected
+	}
+	r.nextID++
+	req.ID = r.nextID
+	ch := make(chan Response, 1)
+	r.pending[req.ID] = ch
+	err := r.enc.Encode(req)
+	if err != nil {
+		delete(r.pending, req.ID)
+	}
+	r.mu.Unlock()
+	if err != nil {
+		return Response{}, ErrDisconnected
+	}
+	select {
+	case resp, ok := <-ch:
+		if !ok {
+			return Response{}, ErrDisconnected
+		}
+		if resp.Type == TypeError {
+			return resp, errorOf(resp.Error)
+		}
+		return resp, nil
+	case <-time.After(requestTimeout):
+		r.mu.Lock()
+		delete(r.pending, req.ID)
+		r.mu.Unlock()
+		return Response{}, ErrDisconnected
+	}
+}
+
+// move sends the move and returns the cells changed since the previous board, including the moves of the other players
+func (r *Remote) move(action game.Action, x, y int) ([]game.Cell, game.GameState, error) {
+	r.mu.Lock()
+	before := append([]Cell(nil), r.board.Cells...)
+	r.mu.Unlock()
+	if _, err := r.request(Request{Type: TypeMove, Action: action, X: x, Y: y}); err != nil {
+		return nil, r.State(), err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var cells []game.Cell
+	for i, cell := range r.board.Cells {
+		if i >= len(before) || cell != before[i] {
+			cells = append(cells, r.board.cell(i/r.board.Height, i%r.board.Height))
+		}
+	}
+	return cells, r.board.State, nil
+}
+
+// Close leaves the room and closes the connection
+func (r *Remote) Close() error {
+	r.request(Request{Type: TypeLeave})
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	return r.conn.Close()
+}
+
+// Changes signals that the board or the players were changed by the server
+func (r *Remote) Changes() <-chan struct{} {
+	return r.changes
+}
+
+// Connected reports whether the session is alive, false once the reconnection failed or the game was closed
+func (r *Remote) Connected() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return !r.closed
+}
+
+// Room returns the room name and its mode
+func (r *Remote) Room() (string, Mode) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.room, r.mode
+}
+
+// Players returns the progress of every player of the room and the winner of the race
+func (r *Remote) Players() ([]PlayerStatus, string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]PlayerStatus(nil), r.players...), r.winner
+}
+
+func (r *Remote) Reveal(x, y int) ([]game.Cell, game.GameState, error) {
+	return r.move(game.ActionReveal, x, y)
+}
+
+func (r *Remote) Chord(x, y int) ([]game.Cell, game.GameState, error) {
+	return r.move(game.ActionChord, x, y)
+}
+
+func (r *Remote) Flag(x, y int) error {
+	_, _, err := r.move(game.ActionFlag, x, y)
+	return err
+}
+
+func (r *Remote) Question(x, y int) error {
+	_, _, err := r.move(game.ActionQuestion, x, y)
+	return err
+}
+
+func (r *Remote) Unflag(x, y int) error {
+	_, _, err := r.move(game.ActionUnflag, x, y)
+	return err
+}
+
+// Start is not supported, the board is set by the room
+func (r *Remote) Start(size, difficulty int, opts ...game.Option) error {
+	return ErrNotSupported
+}
+
+// StartRect is not supported, the board is set by the room
+func (r *Remote) StartRect(width, height, difficulty int, opts ...game.Option) error {
+	return ErrNotSupported
+}
+
+func (r *Remote) Flagged(x, y int) bool {
+	c, err := r.Cell(x, y)
+	return err == nil && c.State() == game.Flagged
+}
+
+func (r *Remote) Cell(x, y int) (game.Cell, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if x < 0 || x >= r.board.Width || y < 0 || y >= r.board.Height {
+		return game.Cell{}, game.ErrInvalidPosition
+	}
+	return r.board.cell(x, y), nil
+}
+
+func (r *Remote) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.board.Remaining
+}
+
+func (r *Remote) Mines() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.board.Mines
+}
+
+// BBBV returns the 3BV of the board, known once the game is over
+func (r *Remote) BBBV() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.board.BBBV
+}
+
+// Stats returns the 3BV only, the layout is not known to the client
+func (r *Remote) Stats() analysis.Stats {
+	return analysis.Stats{BBBV: r.BBBV()}
+}
+
+// Seed returns the seed of the room, the server sends it once the game or the race is over. Zero before
+func (r *Remote) Seed() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.seed
+}
+
+func (r *Remote) State() game.GameState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.board.State
+}
+
+func (r *Remote) Moves() int {
+	return r.Counters().Total()
+}
+
+func (r *Remote) Counters() game.Counters {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.board.Counters
+}
+
+// StartedAt returns the start time as seen by the client
+func (r *Remote) StartedAt() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.board.Started {
+		return time.Time{}
+	}
+	return r.received.Add(-r.board.Elapsed)
+}
+
+// EndedAt returns the time the client got the finished board
+func (r *Remote) EndedAt() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.board.State == game.InProgress {
+		return time.Time{}
+	}
+	return r.received
+}
+
+func (r *Remote) Elapsed() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.board.Started && r.board.State == game.InProgress {
+		return r.board.Elapsed + time.Since(r.received)
+	}
+	return r.board.Elapsed
+}
+
+func (r *Remote) Board() [][]game.Cell {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	board := make([][]game.Cell, r.board.Width)
+	for x := range board {
+		board[x] = make([]game.Cell, r.board.Height)
+		for y := range board[x] {
+			board[x][y] = r.board.cell(x, y)
+		}
+	}
+	return board
+}
+
+func (r *Remote) Snapshot() game.Snapshot {
+	cells := r.Board()
+	r.mu.Lock()
+	b := r.board
+	r.mu.Unlock()
+	return game.Snapshot{
+		Width:     b.Width,
+		Height:    b.Height,
+		Cells:     cells,
+		State:     b.State,
+		Remaining: b.Remaining,
+		Elapsed:   r.Elapsed(),
+		Moves:     b.Counters.Total(),
+		Counters:  b.Counters,
+	}
+}
+
+// Undo is not supported, the moves are shared with the other players
+func (r *Remote) Undo() ([]game.Cell, game.GameState, error) {
+	return nil, r.State(), ErrNotSupported
+}
+
+// Redo is not supported, the moves are shared with the other players
+func (r *Remote) Redo() ([]game.Cell, game.GameState, error) {
+	return nil, r.State(), ErrNotSupported
+}
+
+// Eligible is false, the multiplayer games are not recorded in the high-score table
+func (r *Remote) Eligible() bool {
+	return false
+}
+
+// Hint is not supported in the multiplayer game
+func (r *Remote) Hint() (game.Hint, error) {
+	return game.Hint{}, ErrNotSupported
+}
+
+// Replay returns the empty replay, the moves are kept by the server
+func (r *Remote) Replay() game.Replay {
+	return game.Replay{}
+}
+
+func (r *Remote) Save(w io.Writer) error {
+	return ErrNotSupported
+}
+
+func (r *Remote) Load(rd io.Reader, opts ...game.Option) error {
+	return ErrNotSupported
+}
+
+var _ game.Game = (*Remote)(nil)
@@ -0,0 +1,523 @@
+// Package server hosts the multiplayer games over TCP, the messages are JSON lines. The players join rooms
+// to play one board together or to race on the boards with the same layout. The server owns the games:
+// it validates every move and sends the player-visible boards only
+package server
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/miner/game"
+	"github.com/miner/logger"
+)
+
+const (
+	defaultReconnectTimeout = time.Minute
+	writeTimeout            = 5 * time.Second
+	// maxBoardSide limits the board of the room, it is allocated for every racer and sent with every move
+	maxBoardSide = 100
+)
+
+type Option func(*Server)
+
+// WithReconnectTimeout sets how long the disconnected player keeps the place in the room. Default is one minute
+func WithReconnectTimeout(d time.Duration) Option {
+	return func(s *Server) {
+		s.reconnect = d
+	}
+}
+
+type Server struct {
+	log       logger.Logger
+	reconnect time.Duration
+
+	mu       sync.Mutex
+	rooms    map[string]*room
+	sessions map[string]*player
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	closed   bool
+}
+
+// room is the board settings and the players. Everything of the room, including its players, is guarded by mu.
+// The race is over once it has the winner or none of the racers still plays, it takes no moves and no racers then
+type room struct {
+	name   string
+	mode   Mode
+	width  int
+	height int
+	mines  int
+	seed   int64
+
+	mu      sync.Mutex
+	shared  game.Game
+	players []*player
+	winner  string
+	over    bool
+	closed  bool
+}
+
+type player struct {
+	name   string
+	token  string
+	room   *room
+	game   game.Game
+	conn   *conn
+	expire *time.Timer
+	left   bool
+}
+
+// conn is the client connection, the writes of the broadcasts and the answers are serialized
+type conn struct {
+	net.Conn
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func (c *conn) send(r Response) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.SetWriteDeadline(time.Now().Add(writeTimeout))
+	err := c.enc.Encode(r)
+	if err != nil {
+		// the reader sees the closed connection and disconnects the player
+		c.Close()
+	}
+	return err
+}
+
+func New(log logger.Logger, opts ...Option) *Server {
+	s := &Server{
+		log:       log,
+		reconnect: defaultReconnectTimeout,
+		rooms:     make(map[string]*room),
+		sessions:  make(map[string]*player),
+		conns:     make(map[net.Conn]struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// ListenAndServe listens on the TCP address and serves the clients until the server is closed
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve accepts the clients on the listener until the server is closed
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		l.Close()
+		return net.ErrClosed
+	}
+	s.listener = l
+	s.mu.Unlock()
+	s.log.Info("server", "listening on %s", l.Addr())
+	for {
+		nc, err := l.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return nil
+			}
+			return err
+		}
+		s.mu.Lock()
+		s.conns[nc] = struct{}{}
+		s.mu.Unlock()
+		go s.handle(nc)
+	}
+}
+
+// Close stops accepting the clients and closes all connections
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for nc := range s.conns {
+		nc.Close()
+	}
+	for _, p := range s.sessions {
+		if p.expire != nil {
+			p.expire.Stop()
+		}
+	}
+	if s.listener != nil {
+		return s.listener.Close()
+	}
+	return nil
+}
+
+func (s *Server) handle(nc net.Conn) {
+	c := &conn{Conn: nc, enc: json.NewEncoder(nc)}
+	defer func() {
+		nc.Close()
+		s.mu.Lock()
+		delete(s.conns, nc)
+		s.mu.Unlock()
+	}()
+	var p *player
+	dec := json.NewDecoder(bufio.NewReader(nc))
+	for {
+		var req Request
+		if err := dec.Decode(&req); err != nil {
+			break
+		}
+		var err error
+		switch req.Type {
+		case TypeJoin:
+			if p != nil {
+				err = ErrAlreadyJoined
+				break
+			}
+			p, err = s.join(c, req)
+		case TypeMove:
+			if p == nil {
+				err = ErrNotJoined
+				break
+			}
+			err = s.move(p, req)
+		case TypeLeave:
+			if p == nil {
+				err = ErrNotJoined
+				break
+			}
+			s.leave(p, req.ID)
+			p = nil
+		default:
+			err = ErrUnknownRequest
+		}
+		if err != nil {
+			c.send(Response{ID: req.ID, Type: TypeError, Error: err.Error()})
+		}
+	}
+	if p != nil {
+		s.disconnect(p, c)
+	}
+}
+
+// join adds the player to the room, the room is created with the settings of the request if it does not exist
+func (s *Server) join(c *conn, req Request) (*player, error) {
+	if req.Token != "" {
+		return s.resume(c, req)
+	}
+	if req.Room == "" || req.Player == "" {
+		return nil, ErrInvalidJoin
+	}
+	// the oversized settings are refused before the room is created under the server lock
+	if req.Width > maxBoardSide || req.Height > maxBoardSide {
+		return nil, ErrInvalidSettings
+	}
+	for {
+		s.mu.Lock()
+		r, ok := s.rooms[req.Room]
+		if !ok {
+			var err error
+			if r, err = newRoom(req); err != nil {
+				s.mu.Unlock()
+				return nil, err
+			}
+			s.rooms[req.Room] = r
+			s.log.Info("server", "room %s created: %s %dx%dx%d seed %d", r.name, r.mode, r.width, r.height, r.mines, r.seed)
+		}
+		s.mu.Unlock()
+
+		r.mu.Lock()
+		if r.closed {
+			// the last player left while this one was joining, the room is created again
+			r.mu.Unlock()
+			continue
+		}
+		p, err := s.addPlayer(r, c, req)
+		r.mu.Unlock()
+		return p, err
+	}
+}
+
+// addPlayer creates the player in the locked room and sends the board to it
+func (s *Server) addPlayer(r *room, c *conn, req Request) (*player, error) {
+	if req.Mode != "" && req.Mode != r.mode {
+		return nil, ErrRoomMode
+	}
+	if r.over {
+		return nil, ErrRaceOver
+	}
+	for _, other := range r.players {
+		if other.name == req.Player {
+			return nil, ErrNameTaken
+		}
+	}
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	p := &player{name: req.Player, token: token, room: r, game: r.shared, conn: c}
+	if r.mode == ModeRace {
+		if p.game, err = r.newGame(); err != nil {
+			return nil, err
+		}
+		// every racer starts from the same opened centre, so the layouts are the same
+		if _, _, err := p.game.Reveal(r.width/2, r.height/2); err != nil {
+			return nil, err
+		}
+	}
+	r.players = append(r.players, p)
+	s.mu.Lock()
+	s.sessions[token] = p
+	s.mu.Unlock()
+	s.log.Info("server", "%s joined %s", p.name, r.name)
+	c.send(r.joined(p, req.ID))
+	r.broadcast(r.progress(), p)
+	return p, nil
+}
+
+// resume attaches the new connection to the session of the token
+func (s *Server) resume(c *conn, req Request) (*player, error) {
+	s.mu.Lock()
+	p, ok := s.sessions[req.Token]
+	s.mu.Unlock()
+	if !ok {
+		return nil, ErrUnknownToken
+	}
+	r := p.room
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if p.left {
+		return nil, ErrUnknownToken
+	}
+	if p.expire != nil {
+		p.expire.Stop()
+		p.expire = nil
+	}
+	if p.conn != nil && p.conn != c {
+		p.conn.Close()
+	}
+	p.conn = c
+	s.log.Info("server", "%s reconnected to %s", p.name, r.name)
+	c.send(r.joined(p, req.ID))
+	r.broadcast(r.progress(), p)
+	return p, nil
+}
+
+// move applies the move of the player to its game. The mover gets the board in the answer, in the coop mode
+// the other players get it as a broadcast, in the race mode they get the progress of the players
+func (s *Server) move(p *player, req Request) error {
+	switch req.Action {
+	case game.ActionReveal, game.ActionChord, game.ActionFlag, game.ActionQuestion, game.ActionUnflag:
+	default:
+		return ErrInvalidMove
+	}
+	r := p.room
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.over {
+		return ErrRaceOver
+	}
+	before := p.game.State()
+	if _, _, err := (game.Step{Action: req.Action, X: req.X, Y: req.Y}).Apply(p.game); err != nil {
+		return err
+	}
+	board := newBoard(p.game)
+	if r.mode == ModeRace && before == game.InProgress && board.State == game.Win {
+		r.winner = p.name
+		s.log.Info("server", "%s won the race in %s", p.name, r.name)
+	}
+	r.finish()
+	p.send(Response{ID: req.ID, Type: TypeState, Player: p.name, Board: board, Seed: r.revealedSeed()})
+	switch r.mode {
+	case ModeCoop:
+		r.broadcast(Response{Type: TypeState, Player: p.name, Board: board, Seed: r.revealedSeed()}, p)
+	case ModeRace:
+		r.broadcast(r.progress(), nil)
+	}
+	return nil
+}
+
+// leave removes the player from the room, the empty room is closed
+func (s *Server) leave(p *player, id int) {
+	r := p.room
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if p.left {
+		return
+	}
+	p.send(Response{ID: id, Type: TypeLeft, Room: r.name, Player: p.name})
+	s.remove(p)
+}
+
+// disconnect keeps the place of the player in the room until the reconnect timeout
+func (s *Server) disconnect(p *player, c *conn) {
+	r := p.room
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if p.left || p.conn != c {
+		return
+	}
+	p.conn = nil
+	s.log.Info("server", "%s disconnected from %s", p.name, r.name)
+	p.expire = time.AfterFunc(s.reconnect, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if p.conn == nil && !p.left {
+			s.remove(p)
+		}
+	})
+	r.broadcast(r.progress(), nil)
+}
+
+// remove deletes the player from the locked room and its session
+func (s *Server) remove(p *player) {
+	r := p.room
+	p.left = true
+	if p.expire != nil {
+		p.expire.Stop()
+		p.expire = nil
+	}
+	for i, other := range r.players {
+		if other == p {
+			r.players = append(r.players[:i], r.players[i+1:]...)
+			break
+		}
+	}
+	s.log.Info("server", "%s left %s", p.name, r.name)
+	s.mu.Lock()
+	delete(s.sessions, p.token)
+	if len(r.players) == 0 {
+		r.closed = true
+		delete(s.rooms, r.name)
+		s.log.Info("server", "room %s closed", r.name)
+	}
+	s.mu.Unlock()
+	r.finish()
+	r.broadcast(r.progress(), nil)
+}
+
+func newRoom(req Request) (*room, error) {
+	r := &room{name: req.Room, mode: req.Mode, width: req.Width, height: req.Height, mines: req.Mines, seed: req.Seed}
+	if r.mode == "" {
+		r.mode = ModeCoop
+	}
+	if r.mode != ModeCoop && r.mode != ModeRace {
+		return nil, ErrInvalidSettings
+	}
+	if r.seed == 0 {
+		r.seed = time.Now().UnixNano()
+	}
+	g, err := r.newGame()
+	if err != nil {
+		return nil, err
+	}
+	if r.mode == ModeCoop {
+		r.shared = g
+	}
+	return r, nil
+}
+
+func (r *room) newGame() (game.Game, error) {
+	g := game.NewGame()
+	opts := []game.Option{game.WithMines(r.mines), game.WithSeed(r.seed), game.WithFirstClick(game.FirstClickOpening)}
+	if r.mines <= 0 || g.StartRect(r.width, r.height, 0, opts...) != nil {
+		return nil, ErrInvalidSettings
+	}
+	return g, nil
+}
+
+func (r *room) joined(p *player, id int) Response {
+	return Response{
+		ID:      id,
+		Type:    TypeJoined,
+		Room:    r.name,
+		Mode:    r.mode,
+		Seed:    r.revealedSeed(),
+		Token:   p.token,
+		Player:  p.name,
+		Board:   newBoard(p.game),
+		Players: r.statuses(),
+		Winner:  r.winner,
+	}
+}
+
+// finish ends the race once it has the winner or every racer left in the room has lost
+func (r *room) finish() {
+	if r.mode != ModeRace || r.over || len(r.players) == 0 {
+		return
+	}
+	if r.winner == "" {
+		for _, p := range r.players {
+			if p.game.State() == game.InProgress {
+				return
+			}
+		}
+	}
+	r.over = true
+}
+
+// progress is the broadcast of the players, the winner and the seed once the room is over
+func (r *room) progress() Response {
+	return Response{Type: TypePlayers, Players: r.statuses(), Winner: r.winner, Seed: r.revealedSeed()}
+}
+
+// revealedSeed returns the seed once the coop game or the race is over, zero before. The seed regenerates
+// the layout, and in the race every board is opened at the same centre, so it would show every mine
+func (r *room) revealedSeed() int64 {
+	switch {
+	case r.mode == ModeCoop && r.shared.State() == game.InProgress:
+		return 0
+	case r.mode == ModeRace && !r.over:
+		return 0
+	}
+	return r.seed
+}
+
+func (r *room) statuses() []PlayerStatus {
+	statuses := make([]PlayerStatus, 0, len(r.players))
+	for _, p := range r.players {
+		board := newBoard(p.game)
+		statuses = append(statuses, PlayerStatus{
+			Name:      p.name,
+			Connected: p.conn != nil,
+			State:     board.State,
+			Revealed:  board.revealed(),
+			Safe:      board.Width*board.Height - board.Mines,
+		})
+	}
+	return statuses
+}
+
+// broadcast sends the response to every connected player except the given one
+func (r *room) broadcast(resp Response, except *player) {
+	for _, p := range r.players {
+		if p != except {
+			p.send(resp)
+		}
+	}
+}
+
+func (p *player) send(resp Response) {
+	if p.conn != nil {
+		p.conn.send(resp)
+	}
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.New("token: " + err.Error())
+	}
+	return hex.EncodeToString(b), nil
+}
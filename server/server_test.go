@@ -0,0 +1,271 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/miner/bot"
+	"github.com/miner/game"
+	"github.com/miner/logger"
+)
+
+func startServer(t *testing.T, opts ...Option) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(logger.NewLog(), opts...)
+	go s.Serve(l)
+	t.Cleanup(func() { s.Close() })
+	return l.Addr().String()
+}
+
+func dial(t *testing.T, addr string, join Join) *Remote {
+	t.Helper()
+	r, err := Dial(addr, join)
+	if err != nil {
+		t.Fatalf("join %s: %v", join.Player, err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+// eventually fails the test if the condition is not met within two seconds
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServer_Coop(t *testing.T) {
+	addr := startServer(t)
+	join := Join{Room: "coop", Player: "ann", Width: 9, Height: 9, Mines: 10, Seed: 7}
+	ann := dial(t, addr, join)
+	join.Player = "bob"
+	bob := dial(t, addr, join)
+
+	cells, state, err := ann.Reveal(4, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cells) == 0 || state != game.InProgress {
+		t.Fatalf("expected revealed cells in progress, got %d cells, %v", len(cells), state)
+	}
+	if ann.Seed() != 0 {
+		t.Fatalf("expected the seed hidden during the game, got %d", ann.Seed())
+	}
+	eventually(t, "the move of ann on the board of bob", func() bool {
+		return reflect.DeepEqual(ann.Board(), bob.Board())
+	})
+	if err := bob.Flag(0, 0); err != nil && !errors.Is(err, game.ErrCellRevealed) {
+		t.Fatal(err)
+	}
+	eventually(t, "the flag of bob on the board of ann", func() bool {
+		return reflect.DeepEqual(ann.Board(), bob.Board())
+	})
+	players, _ := ann.Players()
+	if len(players) != 2 || players[0].Name != "ann" || players[1].Name != "bob" {
+		t.Fatalf("expected ann and bob, got %+v", players)
+	}
+}
+
+func TestServer_Race(t *testing.T) {
+	addr := startServer(t)
+	join := Join{Room: "race", Player: "ann", Mode: ModeRace, Width: 6, Height: 6, Mines: 3, Seed: 3}
+	ann := dial(t, addr, join)
+	join.Player = "bob"
+	bob := dial(t, addr, join)
+
+	if !reflect.DeepEqual(ann.Board(), bob.Board()) {
+		t.Fatal("expected the same opened boards")
+	}
+	if ann.Seed() != 0 || bob.Seed() != 0 {
+		t.Fatalf("expected the seed hidden during the race, got %d and %d", ann.Seed(), bob.Seed())
+	}
+	state, _, err := bot.Play(ann)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state != game.Win {
+		t.Fatalf("expected the bot to win, got %v", state)
+	}
+	eventually(t, "the winner", func() bool {
+		_, winner := bob.Players()
+		return winner == "ann"
+	})
+	eventually(t, "the seed after the race", func() bool {
+		return bob.Seed() == 3
+	})
+	if _, _, err := bob.Reveal(0, 0); !errors.Is(err, ErrRaceOver) {
+		t.Fatalf("expected: %v, got: %v", ErrRaceOver, err)
+	}
+	if bob.State() != game.InProgress {
+		t.Fatalf("expected the board of bob untouched, got %v", bob.State())
+	}
+}
+
+func TestServer_RaceLost(t *testing.T) {
+	addr := startServer(t)
+	join := Join{Room: "race", Player: "ann", Mode: ModeRace, Width: 6, Height: 6, Mines: 3, Seed: 3}
+	ann := dial(t, addr, join)
+	join.Player = "bob"
+	bob := dial(t, addr, join)
+
+	g := game.NewGame()
+	if err := g.StartRect(6, 6, 0, game.WithMines(3), game.WithSeed(3), game.WithFirstClick(game.FirstClickOpening), game.WithDebug()); err != nil {
+		t.Fatal(err)
+	}
+	g.Reveal(3, 3)
+	layout, err := g.Layout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mine := 0
+	for !layout.Mines[mine] {
+		mine++
+	}
+	x, y := mine/layout.Height, mine%layout.Height
+
+	if _, state, err := ann.Reveal(x, y); err != nil || state != game.Lose {
+		t.Fatalf("expected ann to lose, got %v %v", state, err)
+	}
+	if ann.Seed() != 0 {
+		t.Fatalf("expected the seed hidden while bob races, got %d", ann.Seed())
+	}
+	if _, state, err := bob.Reveal(x, y); err != nil || state != game.Lose {
+		t.Fatalf("expected bob to lose, got %v %v", state, err)
+	}
+	eventually(t, "the seed after everybody lost", func() bool {
+		return ann.Seed() == 3 && bob.Seed() == 3
+	})
+	if _, _, err := bob.Reveal(0, 0); !errors.Is(err, ErrRaceOver) {
+		t.Fatalf("expected: %v, got: %v", ErrRaceOver, err)
+	}
+	join.Player = "carl"
+	if _, err := Dial(addr, join); !errors.Is(err, ErrRaceOver) {
+		t.Fatalf("expected the new racer refused with %v, got: %v", ErrRaceOver, err)
+	}
+}
+
+func TestServer_Errors(t *testing.T) {
+	addr := startServer(t)
+	join := Join{Room: "room", Player: "ann", Width: 5, Height: 5, Mines: 3, Seed: 1}
+	ann := dial(t, addr, join)
+
+	tests := map[string]struct {
+		join        Join
+		expectedErr error
+	}{
+		"no player":  {join: Join{Room: "room"}, expectedErr: ErrInvalidJoin},
+		"name taken": {join: join, expectedErr: ErrNameTaken},
+		"other mode": {join: Join{Room: "room", Player: "bob", Mode: ModeRace}, expectedErr: ErrRoomMode},
+		"no mines":   {join: Join{Room: "new", Player: "bob", Width: 5, Height: 5}, expectedErr: ErrInvalidSettings},
+		"too large":  {join: Join{Room: "new", Player: "bob", Width: 200000, Height: 200000, Mines: 3}, expectedErr: ErrInvalidSettings},
+		"bad mode":   {join: Join{Room: "new", Player: "bob", Mode: "duel", Width: 5, Height: 5, Mines: 3}, expectedErr: ErrInvalidSettings},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Dial(addr, tc.join); !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected: %v, got: %v", tc.expectedErr, err)
+			}
+		})
+	}
+
+	if _, _, err := ann.Reveal(5, 0); !errors.Is(err, game.ErrInvalidPosition) {
+		t.Fatalf("expected: %v, got: %v", game.ErrInvalidPosition, err)
+	}
+	if _, err := ann.request(Request{Type: TypeMove, Action: game.ActionUndo}); !errors.Is(err, ErrInvalidMove) {
+		t.Fatalf("expected: %v, got: %v", ErrInvalidMove, err)
+	}
+	if _, err := ann.request(Request{Type: TypeJoin, Room: "room", Player: "ann"}); !errors.Is(err, ErrAlreadyJoined) {
+		t.Fatalf("expected: %v, got: %v", ErrAlreadyJoined, err)
+	}
+	if _, _, err := ann.Undo(); !errors.Is(err, ErrNotSupported) {
+		t.Fatalf("expected: %v, got: %v", ErrNotSupported, err)
+	}
+}
+
+func TestServer_Reconnect(t *testing.T) {
+	addr := startServer(t)
+	join := Join{Room: "room", Player: "ann", Width: 9, Height: 9, Mines: 10, Seed: 7}
+	ann := dial(t, addr, join)
+	join.Player = "bob"
+	bob := dial(t, addr, join)
+	if _, _, err := ann.Reveal(4, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	ann.mu.Lock()
+	ann.conn.Close()
+	ann.mu.Unlock()
+	eventually(t, "the move of ann after the reconnection", func() bool {
+		err := ann.Flag(0, 8)
+		return err == nil || errors.Is(err, game.ErrCellRevealed)
+	})
+	eventually(t, "ann connected", func() bool {
+		players, _ := bob.Players()
+		return len(players) == 2 && players[0].Connected
+	})
+	eventually(t, "the same boards", func() bool {
+		return reflect.DeepEqual(ann.Board(), bob.Board())
+	})
+}
+
+func TestServer_Expire(t *testing.T) {
+	addr := startServer(t, WithReconnectTimeout(50*time.Millisecond))
+	nc, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(bufio.NewReader(nc))
+	json.NewEncoder(nc).Encode(Request{Type: TypeJoin, Room: "room", Player: "ann", Width: 5, Height: 5, Mines: 3})
+	var resp Response
+	if err := dec.Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Type != TypeJoined || resp.Token == "" || resp.Mode != ModeCoop || resp.Seed != 0 {
+		t.Fatalf("expected the joined response, got %+v", resp)
+	}
+	nc.Close()
+	time.Sleep(200 * time.Millisecond)
+
+	if _, err := Dial(addr, Join{Room: "room", Player: "x"}); err == nil {
+		t.Fatal("expected the room closed and the empty settings rejected")
+	}
+	r := &Remote{addr: addr, pending: make(map[int]chan Response), changes: make(chan struct{}, 1)}
+	if err := r.connect(Request{Type: TypeJoin, Token: resp.Token}); !errors.Is(err, ErrUnknownToken) {
+		t.Fatalf("expected: %v, got: %v", ErrUnknownToken, err)
+	}
+}
+
+func TestServer_Leave(t *testing.T) {
+	addr := startServer(t)
+	join := Join{Room: "room", Player: "ann", Width: 5, Height: 5, Mines: 3, Seed: 1}
+	ann := dial(t, addr, join)
+	join.Player = "bob"
+	bob := dial(t, addr, join)
+
+	if err := ann.Close(); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "ann left", func() bool {
+		players, _ := bob.Players()
+		return len(players) == 1 && players[0].Name == "bob"
+	})
+	if _, _, err := ann.Reveal(0, 0); !errors.Is(err, ErrDisconnected) {
+		t.Fatalf("expected: %v, got: %v", ErrDisconnected, err)
+	}
+	// the name is free again
+	dial(t, addr, Join{Room: "room", Player: "ann"})
+}
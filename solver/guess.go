@@ -0,0 +1,13 @@
+package solver
+
+// Guess returns the unknown cell with the lowest mine probability. Ties go to the first cell column by column
+func (r Result) Guess() (Cell, bool) {
+	best, found := Cell{}, false
+	lowest := 2.0
+	for cell, p := range r.Probabilities {
+		if p < lowest || p == lowest && (cell.X < best.X || cell.X == best.X && cell.Y < best.Y) {
+			best, lowest, found = cell, p, true
+		}
+	}
+	return best, found
+}
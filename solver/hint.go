@@ -0,0 +1,88 @@
+package solver
+
+import "fmt"
+
+// Hint is the single next move with the short reason for it
+type Hint struct {
+	Cell Cell
+	// Mine reports whether the cell is a certain mine, otherwise it is certainly safe
+	Mine bool
+	// Guess reports that no move is certain, Cell is the one least likely to be a mine
+	Guess bool
+	// Probability is the mine probability of the guessed cell
+	Probability float64
+	// Because is the revealed cells the reason is about
+	Because []Cell
+	Reason  string
+}
+
+// Explain returns the simplest certain move of the board: the single-cell rule first, then the subset rule, the mine count
+// and the enumeration of the frontier. Without a certain move the hint is the safest guess. Returns false if no cell is unknown
+func Explain(b Board, mines int) (Hint, bool) {
+	s := &solver{Board: b, mines: mines, deduced: make(map[int]bool)}
+	constraints := s.constraints()
+	for _, c := range constraints {
+		count := b.Cells[c.source]
+		switch {
+		case c.mines == 0 && count == 0:
+			return s.hint(c.cells[0], false, "this 0 has no mines around", c.source), true
+		case c.mines == 0:
+			return s.hint(c.cells[0], false, fmt.Sprintf("this %d already touches %s", count, its(count)), c.source), true
+		case c.mines == len(c.cells):
+			return s.hint(c.cells[0], true, fmt.Sprintf("this %d has no other place for %s", count, its(count)), c.source), true
+		}
+	}
+	for _, a := range constraints {
+		for _, c := range constraints {
+			if len(a.cells) >= len(c.cells) || !contains(c.cells, a.cells) {
+				continue
+			}
+			rest := difference(c.cells, a.cells)
+			outer, inner := b.Cells[c.source], b.Cells[a.source]
+			switch c.mines - a.mines {
+			case 0:
+				reason := fmt.Sprintf("the %d holds all the mines this %d still needs", inner, outer)
+				return s.hint(rest[0], false, reason, c.source, a.source), true
+			case len(rest):
+				reason := fmt.Sprintf("this %d needs more mines than the %d can hold", outer, inner)
+				return s.hint(rest[0], true, reason, c.source, a.source), true
+			}
+		}
+	}
+	cells, left := s.remaining()
+	switch {
+	case len(cells) > 0 && left == 0:
+		return s.hint(cells[0], false, "all mines are found"), true
+	case len(cells) > 0 && left == len(cells):
+		return s.hint(cells[0], true, "every hidden cell left is a mine"), true
+	}
+
+	r := Solve(b, mines)
+	if len(r.Safe) > 0 {
+		return Hint{Cell: r.Safe[0], Reason: "every way the mines can lie around it leaves it safe"}, true
+	}
+	if len(r.Mines) > 0 {
+		return Hint{Cell: r.Mines[0], Mine: true, Reason: "every way the mines can lie around it puts a mine here"}, true
+	}
+	cell, ok := r.Guess()
+	if !ok {
+		return Hint{}, false
+	}
+	p := r.Probabilities[cell]
+	return Hint{Cell: cell, Guess: true, Probability: p, Reason: fmt.Sprintf("no certain move, %.0f%% chance of a mine", p*100)}, true
+}
+
+func (s *solver) hint(i int, mine bool, reason string, because ...int) Hint {
+	h := Hint{Cell: s.cell(i), Mine: mine, Reason: reason}
+	for _, j := range because {
+		h.Because = append(h.Because, s.cell(j))
+	}
+	return h
+}
+
+func its(count int) string {
+	if count == 1 {
+		return "its mine"
+	}
+	return "its mines"
+}
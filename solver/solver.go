@@ -0,0 +1,541 @@
+// Package solver deduces the safe cells and the mines from the player-visible board.
+// It uses the single-cell rules, the subset rule for pairs of constraints and the enumeration of the frontier,
+// the enumeration also gives the mine probability of every unknown cell when a guess is unavoidable
+package solver
+
+import (
+	"math"
+	"sort"
+)
+
+// maxComponent is the largest frontier component enumerated exactly, larger ones get the estimated probabilities
+const maxComponent = 48
+
+// Values of the board cells that are not revealed, revealed cells hold their count
+const (
+	Unknown = -1
+	Flagged = -2
+)
+
+// Cell is the board position
+type Cell struct {
+	X, Y int
+}
+
+// Result is the outcome of the deduction. Probabilities is the mine probability of every unknown cell,
+// filled only when there is no certainly safe cell and the player has to guess
+type Result struct {
+	Safe          []Cell
+	Mines         []Cell
+	Probabilities map[Cell]float64
+}
+
+// Board is the board as a player sees it: the counts of the revealed cells, Unknown and Flagged cells. Cells are numbered column by column
+type Board struct {
+	Width  int
+	Height int
+	Cells  []int
+}
+
+// NewBoard returns the board with all cells unknown
+func NewBoard(width, height int) Board {
+	b := Board{Width: width, Height: height, Cells: make([]int, width*height)}
+	for i := range b.Cells {
+		b.Cells[i] = Unknown
+	}
+	return b
+}
+
+func (b Board) cell(i int) Cell {
+	return Cell{i / b.Height, i % b.Height}
+}
+
+// Neighbours appends the indexes of the cells around i to buf
+func (b Board) Neighbours(i int, buf []int) []int {
+	x, y := i/b.Height, i%b.Height
+	for dx := -1; dx <= 1; dx++ {
+		for dy := -1; dy <= 1; dy++ {
+			nx, ny := x+dx, y+dy
+			if (dx != 0 || dy != 0) && nx >= 0 && nx < b.Width && ny >= 0 && ny < b.Height {
+				buf = append(buf, nx*b.Height+ny)
+			}
+		}
+	}
+	return buf
+}
+
+// constraint is the number of mines among the unknown cells around the revealed source cell
+type constraint struct {
+	source int
+	cells  []int
+	mines  int
+}
+
+type solver struct {
+	Board
+	mines   int
+	deduced map[int]bool
+}
+
+// Solve returns the certainly safe cells and the certain mines of the visible board with the given total number of mines.
+// Flagged cells are taken as mines
+func Solve(b Board, mines int) Result {
+	s := &solver{Board: b, mines: mines, deduced: make(map[int]bool)}
+	s.deduce()
+	var probabilities map[int]float64
+	if !s.hasSafe() {
+		probabilities = s.enumerate()
+	}
+	return s.result(probabilities)
+}
+
+func (s *solver) open(i int) bool {
+	if s.Cells[i] != Unknown {
+		return false
+	}
+	_, ok := s.deduced[i]
+	return !ok
+}
+
+func (s *solver) mine(i int) bool {
+	if s.Cells[i] == Flagged {
+		return true
+	}
+	return s.deduced[i]
+}
+
+func (s *solver) hasSafe() bool {
+	for _, mine := range s.deduced {
+		if !mine {
+			return true
+		}
+	}
+	return false
+}
+
+// constraints collects the constraints of the revealed cells next to the open cells
+func (s *solver) constraints() []constraint {
+	var constraints []constraint
+	buf := make([]int, 0, 8)
+	for i, count := range s.Cells {
+		if count < 0 {
+			continue
+		}
+		c := constraint{source: i, mines: count}
+		for _, n := range s.Neighbours(i, buf[:0]) {
+			switch {
+			case s.open(n):
+				c.cells = append(c.cells, n)
+			case s.mine(n):
+				c.mines--
+			}
+		}
+		if len(c.cells) > 0 {
+			constraints = append(constraints, c)
+		}
+	}
+	return constraints
+}
+
+// remaining returns the open cells and the number of mines among them
+func (s *solver) remaining() ([]int, int) {
+	var cells []int
+	mines := s.mines
+	for i := range s.Cells {
+		switch {
+		case s.open(i):
+			cells = append(cells, i)
+		case s.mine(i):
+			mines--
+		}
+	}
+	return cells, mines
+}
+
+func (s *solver) mark(cells []int, mine bool) bool {
+	changed := false
+	for _, i := range cells {
+		if s.open(i) {
+			s.deduced[i] = mine
+			changed = true
+		}
+	}
+	return changed
+}
+
+// deduce applies the single-cell, subset and mine count rules until nothing changes
+func (s *solver) deduce() {
+	for {
+		constraints := s.constraints()
+		changed := false
+		for _, c := range constraints {
+			switch c.mines {
+			case 0:
+				changed = s.mark(c.cells, false) || changed
+			case len(c.cells):
+				changed = s.mark(c.cells, true) || changed
+			}
+		}
+		if changed {
+			continue
+		}
+		if s.subsets(constraints) {
+			continue
+		}
+		cells, mines := s.remaining()
+		switch {
+		case len(cells) > 0 && mines == 0:
+			s.mark(cells, false)
+		case len(cells) > 0 && mines == len(cells):
+			s.mark(cells, true)
+		default:
+			return
+		}
+	}
+}
+
+// subsets applies the rule for constraint A inside constraint B: the cells of B outside A hold the difference of their mines
+func (s *solver) subsets(constraints []constraint) bool {
+	byCell := make(map[int][]int)
+	for ci, c := range constraints {
+		for _, i := range c.cells {
+			byCell[i] = append(byCell[i], ci)
+		}
+	}
+	changed := false
+	for ai, a := range constraints {
+		for _, bi := range byCell[a.cells[0]] {
+			b := constraints[bi]
+			if ai == bi || len(a.cells) >= len(b.cells) || !contains(b.cells, a.cells) {
+				continue
+			}
+			rest := difference(b.cells, a.cells)
+			switch b.mines - a.mines {
+			case 0:
+				changed = s.mark(rest, false) || changed
+			case len(rest):
+				changed = s.mark(rest, true) || changed
+			}
+		}
+	}
+	return changed
+}
+
+// contains reports whether the sorted set holds every cell of the sorted subset
+func contains(set, subset []int) bool {
+	j := 0
+	for _, i := range set {
+		if j < len(subset) && subset[j] == i {
+			j++
+		}
+	}
+	return j == len(subset)
+}
+
+func difference(set, subset []int) []int {
+	rest := make([]int, 0, len(set)-len(subset))
+	j := 0
+	for _, i := range set {
+		if j < len(subset) && subset[j] == i {
+			j++
+			continue
+		}
+		rest = append(rest, i)
+	}
+	return rest
+}
+
+// component is the group of frontier cells connected by constraints. counts[k] is the number of solutions with k mines,
+// cellCounts[k][j] is how many of them have the mine in the j-th cell
+type component struct {
+	cells       []int
+	constraints []constraint
+	counts      []float64
+	cellCounts  [][]float64
+}
+
+// enumerate computes the mine probability of every open cell from all assignments of the frontier consistent with the constraints
+// and the total number of mines. Cells that are safe or mines in every assignment are added to the deduced ones
+func (s *solver) enumerate() map[int]float64 {
+	constraints := s.constraints()
+	components := split(constraints)
+	cells, mines := s.remaining()
+	frontier := make(map[int]bool)
+	for _, comp := range components {
+		for _, i := range comp.cells {
+			frontier[i] = true
+		}
+	}
+	interior := len(cells) - len(frontier)
+
+	probabilities := make(map[int]float64, len(cells))
+	for _, comp := range components {
+		if len(comp.cells) > maxComponent {
+			continue
+		}
+		comp.solve()
+	}
+	exact := make([]*component, 0, len(components))
+	for _, comp := range components {
+		if comp.counts != nil {
+			exact = append(exact, comp)
+		} else {
+			estimate(comp, probabilities)
+		}
+	}
+
+	all := convolve(exact, -1)
+	weight := weights(interior, mines, len(all))
+	var total, interiorMines float64
+	for k, n := range all {
+		w := n * weight[k]
+		total += w
+		interiorMines += w * float64(mines-k)
+	}
+	if total == 0 {
+		// the visible board contradicts itself, for example with a wrong flag
+		for _, i := range cells {
+			if _, ok := probabilities[i]; !ok {
+				probabilities[i] = float64(mines) / float64(len(cells))
+			}
+		}
+		return probabilities
+	}
+	for ci, comp := range exact {
+		others := convolve(exact, ci)
+		for j, i := range comp.cells {
+			var p float64
+			for k := range comp.counts {
+				if comp.cellCounts[k][j] == 0 {
+					continue
+				}
+				for ko, n := range others {
+					p += comp.cellCounts[k][j] * n * weight[k+ko]
+				}
+			}
+			probabilities[i] = p / total
+			switch {
+			case p == 0:
+				s.deduced[i] = false
+			case p >= total*(1-1e-12):
+				s.deduced[i] = true
+			}
+		}
+	}
+	if interior > 0 {
+		p := interiorMines / total / float64(interior)
+		for _, i := range cells {
+			if !frontier[i] {
+				probabilities[i] = p
+				switch {
+				case interiorMines == 0:
+					s.deduced[i] = false
+				case p >= 1-1e-12:
+					s.deduced[i] = true
+				}
+			}
+		}
+	}
+	return probabilities
+}
+
+// split groups the constraints into the components with disjoint cells
+func split(constraints []constraint) []*component {
+	parent := make(map[int]int)
+	var find func(int) int
+	find = func(i int) int {
+		if p, ok := parent[i]; ok && p != i {
+			parent[i] = find(p)
+			return parent[i]
+		}
+		parent[i] = i
+		return i
+	}
+	for _, c := range constraints {
+		root := find(c.cells[0])
+		for _, i := range c.cells[1:] {
+			parent[find(i)] = root
+		}
+	}
+	byRoot := make(map[int]*component)
+	var components []*component
+	for _, c := range constraints {
+		root := find(c.cells[0])
+		comp, ok := byRoot[root]
+		if !ok {
+			comp = &component{}
+			byRoot[root] = comp
+			components = append(components, comp)
+		}
+		comp.constraints = append(comp.constraints, c)
+	}
+	for i := range parent {
+		comp := byRoot[find(i)]
+		comp.cells = append(comp.cells, i)
+	}
+	for _, comp := range components {
+		sort.Ints(comp.cells)
+	}
+	return components
+}
+
+// solve counts the assignments of the component by backtracking over its cells
+func (comp *component) solve() {
+	index := make(map[int]int, len(comp.cells))
+	for j, i := range comp.cells {
+		index[i] = j
+	}
+	// byCell lists the constraints of every cell, left is the number of unassigned cells of every constraint
+	byCell := make([][]int, len(comp.cells))
+	need := make([]int, len(comp.constraints))
+	left := make([]int, len(comp.constraints))
+	for ci, c := range comp.constraints {
+		need[ci] = c.mines
+		left[ci] = len(c.cells)
+		for _, i := range c.cells {
+			byCell[index[i]] = append(byCell[index[i]], ci)
+		}
+	}
+	comp.counts = make([]float64, len(comp.cells)+1)
+	comp.cellCounts = make([][]float64, len(comp.cells)+1)
+	for k := range comp.cellCounts {
+		comp.cellCounts[k] = make([]float64, len(comp.cells))
+	}
+	assigned := make([]bool, len(comp.cells))
+	var walk func(j, mines int)
+	walk = func(j, mines int) {
+		if j == len(comp.cells) {
+			comp.counts[mines]++
+			for m, mine := range assigned {
+				if mine {
+					comp.cellCounts[mines][m]++
+				}
+			}
+			return
+		}
+		for _, mine := range []bool{false, true} {
+			ok := true
+			for _, ci := range byCell[j] {
+				left[ci]--
+				if mine {
+					need[ci]--
+				}
+				if need[ci] < 0 || need[ci] > left[ci] {
+					ok = false
+				}
+			}
+			if ok {
+				assigned[j] = mine
+				next := mines
+				if mine {
+					next++
+				}
+				walk(j+1, next)
+			}
+			for _, ci := range byCell[j] {
+				left[ci]++
+				if mine {
+					need[ci]++
+				}
+			}
+		}
+		assigned[j] = false
+	}
+	walk(0, 0)
+}
+
+// estimate sets the probability of the cells of the component too large to enumerate to the highest local mine density
+func estimate(comp *component, probabilities map[int]float64) {
+	for _, c := range comp.constraints {
+		p := float64(c.mines) / float64(len(c.cells))
+		for _, i := range c.cells {
+			if p > probabilities[i] {
+				probabilities[i] = p
+			}
+		}
+	}
+}
+
+// convolve returns the number of solutions by the total number of mines of all components except the skipped one
+func convolve(components []*component, skip int) []float64 {
+	result := []float64{1}
+	for ci, comp := range components {
+		if ci == skip {
+			continue
+		}
+		next := make([]float64, len(result)+len(comp.counts)-1)
+		for a, n := range result {
+			if n == 0 {
+				continue
+			}
+			for b, m := range comp.counts {
+				next[a+b] += n * m
+			}
+		}
+		result = next
+	}
+	return result
+}
+
+func logBinomial(n, k int) float64 {
+	a, _ := math.Lgamma(float64(n + 1))
+	b, _ := math.Lgamma(float64(k + 1))
+	c, _ := math.Lgamma(float64(n - k + 1))
+	return a - b - c
+}
+
+// weights returns the relative number of ways to place the rest of the mines into the interior cells
+// for every number of frontier mines below n. The weights are scaled by the largest one, so they do not overflow
+func weights(interior, mines, n int) []float64 {
+	logs := make([]float64, n)
+	max := math.Inf(-1)
+	for k := range logs {
+		rest := mines - k
+		if rest < 0 || rest > interior {
+			logs[k] = math.Inf(-1)
+			continue
+		}
+		logs[k] = logBinomial(interior, rest)
+		if logs[k] > max {
+			max = logs[k]
+		}
+	}
+	w := make([]float64, n)
+	for k, l := range logs {
+		if !math.IsInf(l, -1) {
+			w[k] = math.Exp(l - max)
+		}
+	}
+	return w
+}
+
+func (s *solver) result(probabilities map[int]float64) Result {
+	var r Result
+	for i, mine := range s.deduced {
+		if mine {
+			r.Mines = append(r.Mines, s.cell(i))
+		} else {
+			r.Safe = append(r.Safe, s.cell(i))
+		}
+	}
+	sortCells(r.Safe)
+	sortCells(r.Mines)
+	if len(r.Safe) == 0 && probabilities != nil {
+		r.Probabilities = make(map[Cell]float64, len(probabilities))
+		for i, p := range probabilities {
+			if s.open(i) {
+				r.Probabilities[s.cell(i)] = p
+			}
+		}
+	}
+	return r
+}
+
+func sortCells(cells []Cell) {
+	sort.Slice(cells, func(a, b int) bool {
+		if cells[a].X != cells[b].X {
+			return cells[a].X < cells[b].X
+		}
+		return cells[a].Y < cells[b].Y
+	})
+}
@@ -0,0 +1,185 @@
+package solver
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// parseBoard builds the board from rows: # is unknown, F is flagged, . is an empty revealed cell, digits are counts
+func parseBoard(rows ...string) Board {
+	b := NewBoard(len(rows[0]), len(rows))
+	for y, row := range rows {
+		for x, r := range row {
+			i := x*b.Height + y
+			switch {
+			case r == 'F':
+				b.Cells[i] = Flagged
+			case r == '.':
+				b.Cells[i] = 0
+			case r != '#':
+				b.Cells[i] = int(r - '0')
+			}
+		}
+	}
+	return b
+}
+
+func TestSolve(t *testing.T) {
+	tests := map[string]struct {
+		rows          []string
+		mines         int
+		safe          []Cell
+		expectedMines []Cell
+		probabilities map[Cell]float64
+	}{
+		"single cell": {
+			rows:          []string{"1#"},
+			mines:         1,
+			expectedMines: []Cell{{1, 0}},
+		},
+		"zero": {
+			rows:  []string{"#.", "##"},
+			mines: 0,
+			safe:  []Cell{{0, 0}, {0, 1}, {1, 1}},
+		},
+		"flag": {
+			rows:  []string{"F1#"},
+			mines: 1,
+			safe:  []Cell{{2, 0}},
+		},
+		"subset": {
+			rows:          []string{"###", "121", "..."},
+			mines:         2,
+			safe:          []Cell{{1, 0}},
+			expectedMines: []Cell{{0, 0}, {2, 0}},
+		},
+		"guess": {
+			rows:          []string{"#1#"},
+			mines:         1,
+			probabilities: map[Cell]float64{{0, 0}: 0.5, {2, 0}: 0.5},
+		},
+		"mine count": {
+			rows:          []string{"#1##"},
+			mines:         2,
+			expectedMines: []Cell{{3, 0}},
+			probabilities: map[Cell]float64{{0, 0}: 0.5, {2, 0}: 0.5},
+		},
+		"interior": {
+			rows:          []string{"###", "###"},
+			mines:         3,
+			probabilities: map[Cell]float64{{0, 0}: 0.5, {0, 1}: 0.5, {1, 0}: 0.5, {1, 1}: 0.5, {2, 0}: 0.5, {2, 1}: 0.5},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := Solve(parseBoard(tc.rows...), tc.mines)
+			if !reflect.DeepEqual(r.Safe, tc.safe) {
+				t.Fatalf("expected safe: %v, got: %v", tc.safe, r.Safe)
+			}
+			if !reflect.DeepEqual(r.Mines, tc.expectedMines) {
+				t.Fatalf("expected mines: %v, got: %v", tc.expectedMines, r.Mines)
+			}
+			if len(r.Probabilities) != len(tc.probabilities) {
+				t.Fatalf("expected probabilities: %v, got: %v", tc.probabilities, r.Probabilities)
+			}
+			for cell, p := range tc.probabilities {
+				if math.Abs(r.Probabilities[cell]-p) > 1e-9 {
+					t.Fatalf("expected probabilities: %v, got: %v", tc.probabilities, r.Probabilities)
+				}
+			}
+		})
+	}
+}
+
+func TestSolve_Probabilities(t *testing.T) {
+	// the 1 on the left has two cells for one mine, the other mine is anywhere in the four interior cells
+	r := Solve(parseBoard("#1####"), 2)
+	expected := map[Cell]float64{{0, 0}: 0.5, {2, 0}: 0.5, {3, 0}: 1.0 / 3, {4, 0}: 1.0 / 3, {5, 0}: 1.0 / 3}
+	for cell, p := range expected {
+		if math.Abs(r.Probabilities[cell]-p) > 1e-9 {
+			t.Fatalf("expected: %v, got: %v", expected, r.Probabilities)
+		}
+	}
+	if guess, _ := r.Guess(); guess != (Cell{3, 0}) {
+		t.Fatalf("expected: %v, got: %v", Cell{3, 0}, guess)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	tests := map[string]struct {
+		rows    []string
+		mines   int
+		cell    Cell
+		mine    bool
+		guess   bool
+		because []Cell
+		reason  string
+	}{
+		"touches its mine": {
+			rows:    []string{"F1#"},
+			mines:   1,
+			cell:    Cell{2, 0},
+			because: []Cell{{1, 0}},
+			reason:  "this 1 already touches its mine",
+		},
+		"no other place": {
+			rows:    []string{"1#", ".."},
+			mines:   1,
+			cell:    Cell{1, 0},
+			mine:    true,
+			because: []Cell{{0, 0}},
+			reason:  "this 1 has no other place for its mine",
+		},
+		"subset": {
+			rows:    []string{"###", "121", "..."},
+			mines:   2,
+			cell:    Cell{2, 0},
+			mine:    true,
+			because: []Cell{{1, 1}, {0, 1}},
+			reason:  "this 2 needs more mines than the 1 can hold",
+		},
+		"mine count": {
+			rows:   []string{"F#"},
+			mines:  1,
+			cell:   Cell{1, 0},
+			reason: "all mines are found",
+		},
+		"enumeration": {
+			rows:   []string{"#1##"},
+			mines:  2,
+			cell:   Cell{3, 0},
+			mine:   true,
+			reason: "every way the mines can lie around it puts a mine here",
+		},
+		"fifty-fifty": {
+			rows:   []string{"#1#"},
+			mines:  1,
+			cell:   Cell{0, 0},
+			guess:  true,
+			reason: "no certain move, 50% chance of a mine",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			h, ok := Explain(parseBoard(tc.rows...), tc.mines)
+			if !ok {
+				t.Fatalf("expected a hint")
+			}
+			if h.Cell != tc.cell || h.Mine != tc.mine || h.Guess != tc.guess {
+				t.Fatalf("expected: %v mine %v guess %v, got: %v mine %v guess %v", tc.cell, tc.mine, tc.guess, h.Cell, h.Mine, h.Guess)
+			}
+			if !reflect.DeepEqual(h.Because, tc.because) {
+				t.Fatalf("expected: %v, got: %v", tc.because, h.Because)
+			}
+			if h.Reason != tc.reason {
+				t.Fatalf("expected: %q, got: %q", tc.reason, h.Reason)
+			}
+		})
+	}
+	if _, ok := Explain(parseBoard("F1"), 1); ok {
+		t.Fatalf("expected no hint without unknown cells")
+	}
+}
@@ -0,0 +1,112 @@
+package tui
+
+import "github.com/miner/logger"
+
+// command is the player input decoded from the terminal keys
+type command int
+
+const (
+	commandNone command = iota
+	commandUp
+	commandDown
+	commandLeft
+	commandRight
+	commandReveal
+	commandFlag
+	commandChord
+	commandUndo
+	commandRedo
+	commandHint
+	commandNew
+	commandQuit
+)
+
+const (
+	keyEscape = 0x1b
+	keyCtrlC  = 0x03
+	keyCtrlD  = 0x04
+)
+
+// parseKeys decodes the bytes read from the terminal. Arrows come as the escape sequences ESC [ A..D, vi keys and WASD move too.
+// The configured bindings are looked up before the built-in keys
+func parseKeys(b []byte, bindings map[byte]command) []command {
+	commands := make([]command, 0, len(b))
+	for i := 0; i < len(b); i++ {
+		if b[i] == keyEscape && i+2 < len(b) && (b[i+1] == '[' || b[i+1] == 'O') {
+			switch b[i+2] {
+			case 'A':
+				commands = append(commands, commandUp)
+			case 'B':
+				commands = append(commands, commandDown)
+			case 'C':
+				commands = append(commands, commandRight)
+			case 'D':
+				commands = append(commands, commandLeft)
+			}
+			i += 2
+			continue
+		}
+		if c, ok := bindings[b[i]]; ok {
+			commands = append(commands, c)
+			continue
+		}
+		if c := keyCommand(b[i]); c != commandNone {
+			commands = append(commands, c)
+		}
+	}
+	return commands
+}
+
+// keyBindings maps the configured undo, redo and hint keys onto the terminal input. The key names are the ones of the window,
+// a letter is typed with Shift as the lower case letters are the built-in keys, a digit as is. Other keys have no terminal byte
+// and keep only the built-in key, pause and step belong to the replay that the terminal does not play
+func keyBindings(keys map[string]string, log logger.Logger) map[byte]command {
+	commands := map[string]command{
+		"undo": commandUndo,
+		"redo": commandRedo,
+		"hint": commandHint,
+	}
+	bindings := make(map[byte]command, len(commands))
+	for name, value := range keys {
+		c, ok := commands[name]
+		if !ok {
+			continue
+		}
+		if len(value) != 1 || !(value[0] >= 'A' && value[0] <= 'Z' || value[0] >= '0' && value[0] <= '9') {
+			log.Warn("tui", "key %q for %s has no terminal key, keeping the built-in one", value, name)
+			continue
+		}
+		bindings[value[0]] = c
+	}
+	return bindings
+}
+
+func keyCommand(b byte) command {
+	switch b {
+	case 'k', 'w':
+		return commandUp
+	case 'j', 's':
+		return commandDown
+	case 'h', 'a':
+		return commandLeft
+	case 'l', 'd':
+		return commandRight
+	case ' ', '\r', '\n':
+		return commandReveal
+	case 'f':
+		return commandFlag
+	case 'c':
+		return commandChord
+	case 'u':
+		return commandUndo
+	case 'r':
+		return commandRedo
+	case '?':
+		return commandHint
+	case 'n':
+		return commandNew
+	case 'q', keyCtrlC, keyCtrlD:
+		return commandQuit
+	}
+	return commandNone
+}
@@ -0,0 +1,83 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/miner/game"
+)
+
+const (
+	ansiReset   = "\x1b[0m"
+	ansiReverse = "\x1b[7m"
+	ansiClear   = "\x1b[H\x1b[2J"
+	ansiGrey    = "\x1b[90m"
+	ansiRed     = "\x1b[31m"
+	ansiYellow  = "\x1b[33m"
+	ansiExplode = "\x1b[97;41m"
+)
+
+// countColors are the ANSI colours of the bomb counts 1..8
+var countColors = [...]string{
+	"\x1b[94m", "\x1b[32m", "\x1b[91m", "\x1b[34m",
+	"\x1b[31m", "\x1b[36m", "\x1b[35m", "\x1b[37m",
+}
+
+const help = "arrows/hjkl move  space reveal  f flag  c chord  u undo  r redo  ? hint  n new  q quit"
+
+// render draws the board with the cursor, the status line and the help line. Every cell is two columns wide
+func render(w io.Writer, s game.Snapshot, cursor position, message string) error {
+	var b strings.Builder
+	b.WriteString(ansiClear)
+	fmt.Fprintf(&b, "mines: %-4d time: %03d  %s\r\n\r\n", s.Remaining, int(s.Elapsed.Seconds()), status(s.State))
+	for y := 0; y < s.Height; y++ {
+		for x := 0; x < s.Width; x++ {
+			color, glyph := cellText(s.Cells[x][y])
+			if x == cursor.x && y == cursor.y {
+				color += ansiReverse
+			}
+			b.WriteString(color + glyph + ansiReset)
+		}
+		b.WriteString("\r\n")
+	}
+	b.WriteString("\r\n")
+	if message != "" {
+		b.WriteString(message + "\r\n")
+	}
+	b.WriteString(ansiGrey + help + ansiReset + "\r\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func status(state game.GameState) string {
+	switch state {
+	case game.Win:
+		return "CONGRATULATIONS!"
+	case game.Lose:
+		return "YOU LOSE!"
+	}
+	return ""
+}
+
+// cellText returns the ANSI colour and the two column glyph of the cell
+func cellText(cell game.Cell) (string, string) {
+	switch cell.State() {
+	case game.Revealed:
+		if cell.Count() == 0 {
+			return "", "  "
+		}
+		return countColors[cell.Count()-1], fmt.Sprintf("%2d", cell.Count())
+	case game.Flagged:
+		return ansiRed, " F"
+	case game.Question:
+		return ansiYellow, " ?"
+	case game.Mine:
+		return ansiRed, " *"
+	case game.Exploded:
+		return ansiExplode, " *"
+	case game.WrongFlag:
+		return ansiYellow, " X"
+	}
+	return ansiGrey, " ."
+}
@@ -0,0 +1,280 @@
+// Package tui is the terminal front-end of the game, it needs only a plain TTY with ANSI colours
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/miner/game"
+	"github.com/miner/internal/sysuser"
+	"github.com/miner/logger"
+	"github.com/miner/scores"
+)
+
+const tickInterval = time.Second
+
+// Settings is the board the terminal games are played on. Zero seed is random, the seed is used for the first game only.
+// NoGuess generates the boards solvable without guessing. Keys are the configured key bindings, see keyBindings
+type Settings struct {
+	Width   int
+	Height  int
+	Mines   int
+	Seed    int64
+	NoGuess bool
+	Keys    map[string]string
+}
+
+type position struct {
+	x, y int
+}
+
+type Client struct {
+	settings Settings
+	bindings map[byte]command
+	game     game.Game
+	in       *os.File
+	out      io.Writer
+	log      logger.Logger
+	cursor   position
+	message  string
+	scores   *scores.Store
+}
+
+func NewClient(log logger.Logger, settings Settings) *Client {
+	var store *scores.Store
+	path, err := scores.DefaultPath()
+	if err != nil {
+		log.Error("tui", "scores.DefaultPath: %v", err)
+	} else {
+		store = scores.NewStore(path)
+	}
+	return &Client{
+		settings: settings,
+		bindings: keyBindings(settings.Keys, log),
+		game:     game.NewGame(),
+		in:       os.Stdin,
+		out:      os.Stdout,
+		log:      log,
+		scores:   store,
+	}
+}
+
+// Run plays in the terminal until the player quits. The terminal is switched to the unbuffered mode without echo and restored on exit
+func (c *Client) Run() error {
+	if err := c.newGame(); err != nil {
+		return err
+	}
+	restore, err := c.rawMode()
+	if err != nil {
+		return err
+	}
+	defer restore()
+	defer io.WriteString(c.out, ansiReset+"\r\n")
+
+	keys := make(chan []byte)
+	go c.readKeys(keys)
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := render(c.out, c.game.Snapshot(), c.cursor, c.message); err != nil {
+			return err
+		}
+		select {
+		case <-interrupt:
+			return nil
+		case <-ticker.C:
+		case b, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			for _, command := range parseKeys(b, c.bindings) {
+				if command == commandQuit {
+					return nil
+				}
+				c.handle(command)
+			}
+		}
+	}
+}
+
+// readKeys sends the raw input chunks until the input is closed
+func (c *Client) readKeys(keys chan<- []byte) {
+	defer close(keys)
+	buf := make([]byte, 64)
+	for {
+		n, err := c.in.Read(buf)
+		if n > 0 {
+			keys <- append([]byte(nil), buf[:n]...)
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				c.log.Error("tui", "Read: %v", err)
+			}
+			return
+		}
+	}
+}
+
+// rawMode turns off the line buffering and echo of the terminal with stty. Returns the function restoring the previous mode
+func (c *Client) rawMode() (func(), error) {
+	saved, err := c.stty("-g")
+	if err != nil {
+		return nil, fmt.Errorf("not a terminal: %w", err)
+	}
+	if _, err := c.stty("-icanon", "-echo", "min", "1"); err != nil {
+		return nil, err
+	}
+	io.WriteString(c.out, "\x1b[?25l")
+	return func() {
+		io.WriteString(c.out, "\x1b[?25h")
+		if _, err := c.stty(strings.TrimSpace(saved)); err != nil {
+			c.log.Error("tui", "stty: %v", err)
+		}
+	}, nil
+}
+
+func (c *Client) stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = c.in
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+func (c *Client) newGame() error {
+	s := c.settings
+	opts := []game.Option{game.WithMines(s.Mines), game.WithFirstClick(game.FirstClickOpening)}
+	if s.Seed != 0 {
+		opts = append(opts, game.WithSeed(s.Seed))
+		c.settings.Seed = 0
+	}
+	if s.NoGuess {
+		opts = append(opts, game.WithNoGuess())
+	}
+	if err := c.game.StartRect(s.Width, s.Height, 0, opts...); err != nil {
+		return err
+	}
+	c.cursor = position{s.Width / 2, s.Height / 2}
+	c.message = ""
+	return nil
+}
+
+// handle applies the player command to the cursor or the cell under it
+func (c *Client) handle(command command) {
+	s := c.settings
+	c.message = ""
+	before := c.game.State()
+	var err error
+	switch command {
+	case commandUp:
+		c.cursor.y = (c.cursor.y + s.Height - 1) % s.Height
+	case commandDown:
+		c.cursor.y = (c.cursor.y + 1) % s.Height
+	case commandLeft:
+		c.cursor.x = (c.cursor.x + s.Width - 1) % s.Width
+	case commandRight:
+		c.cursor.x = (c.cursor.x + 1) % s.Width
+	case commandReveal:
+		err = c.reveal()
+	case commandFlag:
+		err = c.cycleMark()
+	case commandChord:
+		_, _, err = c.game.Chord(c.cursor.x, c.cursor.y)
+	case commandUndo:
+		_, _, err = c.game.Undo()
+	case commandRedo:
+		_, _, err = c.game.Redo()
+	case commandHint:
+		err = c.hint()
+	case commandNew:
+		err = c.newGame()
+	}
+	if err != nil {
+		c.message = err.Error()
+		return
+	}
+	if before == game.InProgress && c.game.State() == game.Win {
+		c.recordScore()
+	}
+}
+
+// reveal opens the cell under the cursor, the revealed cell is chorded
+func (c *Client) reveal() error {
+	cell, err := c.game.Cell(c.cursor.x, c.cursor.y)
+	if err != nil {
+		return err
+	}
+	if cell.State() == game.Revealed {
+		_, _, err = c.game.Chord(c.cursor.x, c.cursor.y)
+		return err
+	}
+	_, _, err = c.game.Reveal(c.cursor.x, c.cursor.y)
+	return err
+}
+
+// hint moves the cursor to the hinted cell and shows the reason
+func (c *Client) hint() error {
+	h, err := c.game.Hint()
+	if err != nil {
+		return err
+	}
+	c.cursor = position{h.X, h.Y}
+	switch {
+	case h.Guess:
+		c.message = "hint: guess, " + h.Reason
+	case h.Mine:
+		c.message = "hint: mine, " + h.Reason
+	default:
+		c.message = "hint: safe, " + h.Reason
+	}
+	return nil
+}
+
+// cycleMark switches the mark of the hidden cell: none -> flag -> question -> none
+func (c *Client) cycleMark() error {
+	cell, err := c.game.Cell(c.cursor.x, c.cursor.y)
+	if err != nil {
+		return err
+	}
+	switch cell.State() {
+	case game.Hidden:
+		return c.game.Flag(c.cursor.x, c.cursor.y)
+	case game.Flagged:
+		return c.game.Question(c.cursor.x, c.cursor.y)
+	case game.Question:
+		return c.game.Unflag(c.cursor.x, c.cursor.y)
+	}
+	return game.ErrCellRevealed
+}
+
+// recordScore saves the won game to the high-score table. Games with undone moves or hints are skipped
+func (c *Client) recordScore() {
+	if c.scores == nil || !c.game.Eligible() {
+		return
+	}
+	board := scores.Board{Width: c.settings.Width, Height: c.settings.Height, Mines: c.game.Mines()}
+	entry := scores.Entry{
+		Name: sysuser.Name(),
+		Time: c.game.Elapsed(),
+		Date: c.game.EndedAt(),
+		BBBV: c.game.BBBV(),
+		Seed: c.game.Seed(),
+	}
+	rank, err := c.scores.Add(board, entry)
+	if err != nil {
+		c.log.Error("tui", "scores.Add: %v", err)
+		return
+	}
+	if rank >= 0 {
+		c.message = fmt.Sprintf("new record #%d on %v", rank+1, board)
+	}
+}
@@ -0,0 +1,108 @@
+package tui
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/miner/game"
+	"github.com/miner/logger"
+)
+
+func TestParseKeys(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		expected []command
+	}{
+		"arrows":         {input: "\x1b[A\x1b[B\x1b[C\x1b[D", expected: []command{commandUp, commandDown, commandRight, commandLeft}},
+		"vi keys":        {input: "kjlh", expected: []command{commandUp, commandDown, commandRight, commandLeft}},
+		"actions":        {input: " fcur?nq", expected: []command{commandReveal, commandFlag, commandChord, commandUndo, commandRedo, commandHint, commandNew, commandQuit}},
+		"unknown":        {input: "zx\x1b[Z", expected: []command{}},
+		"short sequence": {input: "\x1b[", expected: []command{}},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			commands := parseKeys([]byte(tc.input), nil)
+			if !reflect.DeepEqual(commands, tc.expected) {
+				t.Fatalf("expected: %v, got: %v", tc.expected, commands)
+			}
+		})
+	}
+}
+
+func TestKeyBindings(t *testing.T) {
+	bindings := keyBindings(map[string]string{"undo": "B", "redo": "7", "hint": "F1", "pause": "P"}, logger.NewLog())
+	expected := map[byte]command{'B': commandUndo, '7': commandRedo}
+	if !reflect.DeepEqual(bindings, expected) {
+		t.Fatalf("expected: %v, got: %v", expected, bindings)
+	}
+	commands := parseKeys([]byte("B7ur?P"), bindings)
+	if want := []command{commandUndo, commandRedo, commandUndo, commandRedo, commandHint}; !reflect.DeepEqual(commands, want) {
+		t.Fatalf("expected: %v, got: %v", want, commands)
+	}
+}
+
+func TestClient_Handle(t *testing.T) {
+	c := &Client{settings: Settings{Width: 5, Height: 4, Mines: 3}, game: game.NewGame()}
+	if err := c.newGame(); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	for _, command := range parseKeys([]byte("kkkll"), nil) {
+		c.handle(command)
+	}
+	if c.cursor != (position{4, 3}) {
+		t.Fatalf("expected the cursor to wrap around, got: %v", c.cursor)
+	}
+	c.handle(commandFlag)
+	if !c.game.Flagged(4, 3) {
+		t.Fatalf("expected the cell under the cursor to be flagged")
+	}
+	c.handle(commandReveal)
+	if c.message != game.ErrCellFlagged.Error() {
+		t.Fatalf("expected: %v, got: %v", game.ErrCellFlagged, c.message)
+	}
+	c.handle(commandFlag)
+	c.handle(commandFlag)
+	c.handle(commandReveal)
+	if c.message != "" || c.game.Moves() != 4 {
+		t.Fatalf("expected the reveal to be accepted, got: %q %v", c.message, c.game.Moves())
+	}
+}
+
+func TestClient_Hint(t *testing.T) {
+	c := &Client{settings: Settings{Width: 5, Height: 4, Mines: 3}, game: game.NewGame()}
+	if err := c.newGame(); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	c.cursor = position{0, 0}
+	c.handle(commandHint)
+	if c.cursor != (position{2, 2}) || c.message != "hint: safe, the first reveal is always safe" {
+		t.Fatalf("expected the cursor on the hinted cell, got: %v %q", c.cursor, c.message)
+	}
+	if c.game.Counters().Hints != 1 || c.game.Eligible() {
+		t.Fatalf("expected the hint to be counted")
+	}
+}
+
+func TestRender(t *testing.T) {
+	g := game.NewGame()
+	if err := g.StartRect(3, 2, 0, game.WithMines(1), game.WithSeed(1)); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	g.Flag(1, 1)
+	var buf bytes.Buffer
+	if err := render(&buf, g.Snapshot(), position{1, 1}, "hello"); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+	out := buf.String()
+	for _, expected := range []string{"mines: 0", ansiRed + ansiReverse + " F", "hello", help} {
+		if !strings.Contains(out, expected) {
+			t.Fatalf("expected %q in the output: %q", expected, out)
+		}
+	}
+	if rows := strings.Count(out, ansiGrey+" ."); rows != 5 {
+		t.Fatalf("expected: %v, got: %v", 5, rows)
+	}
+}
@@ -22,8 +22,7 @@ type cellButton struct {
 	button
 	Selected bool
 	x, y     int
-	revealed bool
-	count    int
+	marker   render.Renderable
 	Position Position
 }
 
@@ -41,6 +40,17 @@ type sizeButton struct {
 	button
 }
 
+type inputField struct {
+	button
+	label   string
+	value   *string
+	focused bool
+}
+
+func (f *inputField) String() string {
+	return f.label + ": " + *f.value
+}
+
 type startButton struct {
 	button
 }
@@ -48,3 +58,21 @@ type startButton struct {
 type backButton struct {
 	button
 }
+
+type sceneButton struct {
+	button
+	scene string
+}
+
+type toggleButton struct {
+	button
+	label string
+	value *bool
+}
+
+func (t *toggleButton) String() string {
+	if *t.value {
+		return t.label + ": on"
+	}
+	return t.label + ": off"
+}
@@ -1,8 +1,13 @@
 package ui
 
 import (
+	"strconv"
+
+	"github.com/miner/config"
 	"github.com/miner/game"
 	"github.com/miner/logger"
+	"github.com/miner/scores"
+	"github.com/miner/server"
 	"github.com/oakmound/oak/v4"
 	"github.com/oakmound/oak/v4/render"
 )
@@ -10,29 +15,91 @@ import (
 type Client struct {
 	size       Size
 	difficulty Difficulty
+	custom     customBoard
 	game       game.Game
 	window     *oak.Window
 	font       *render.Font
 	log        logger.Logger
 	grid       *Grid
+	scores     *scores.Store
+	resume     bool
+	result     render.Renderable
+	summary    render.Renderable
+	hints      []render.Renderable
+	replaying  bool
+	replayPath string
+	startOpts  []game.Option
+	noGuess    bool
+	remote     *server.Remote
+}
+
+// Board is the board the game starts on when the settings scene is skipped. Zero seed is random,
+// NoGuess generates the boards solvable without guessing
+type Board struct {
+	Width   int
+	Height  int
+	Mines   int
+	Seed    int64
+	NoGuess bool
 }
 
-func NewClient(log logger.Logger) *Client {
+// NewClient creates the window client, the config replaces the built-in presets, palette, window size and keys
+func NewClient(log logger.Logger, cfg config.Config) *Client {
+	configure(cfg, log)
 	window := oak.NewWindow()
 	font, err := newFont()
 	if err != nil {
 		log.Error("ui", "NewFont: %v", err)
 	}
+	var store *scores.Store
+	path, err := scores.DefaultPath()
+	if err != nil {
+		log.Error("ui", "scores.DefaultPath: %v", err)
+	} else {
+		store = scores.NewStore(path)
+	}
 	return &Client{
 		window: window,
 		font:   font,
 		log:    log,
+		custom: customBoard{width: "30", height: "16", mines: "99"},
+		scores: store,
 	}
 }
 
+// Run opens the window with the settings scene
 func (c *Client) Run() error {
-	var err error
 	c.game = game.NewGame()
+	return c.run("settings")
+}
+
+// RunBoard opens the window with the game on the given board, skipping the settings scene
+func (c *Client) RunBoard(b Board) error {
+	c.size = sizeCustom
+	c.custom = customBoard{width: strconv.Itoa(b.Width), height: strconv.Itoa(b.Height), mines: strconv.Itoa(b.Mines)}
+	if _, _, _, err := c.custom.parse(); err != nil {
+		return err
+	}
+	c.noGuess = b.NoGuess
+	if b.Seed != 0 {
+		c.startOpts = []game.Option{game.WithSeed(b.Seed)}
+	}
+	c.game = game.NewGame()
+	if err := c.window.AddScene("game", c.newGameScene()); err != nil {
+		return err
+	}
+	return c.run("game")
+}
+
+// RunReplay opens the window with the playback of the replay file
+func (c *Client) RunReplay(path string) error {
+	c.game = game.NewGame()
+	c.replayPath = path
+	return c.run("replay")
+}
+
+func (c *Client) run(first string) error {
+	var err error
 
 	err = c.window.AddScene("settings", c.newSettingScene())
 	if err != nil {
@@ -42,7 +109,15 @@ func (c *Client) Run() error {
 	if err != nil {
 		return err
 	}
-	err = c.window.Init("settings")
+	err = c.window.AddScene("scores", c.newScoresScene())
+	if err != nil {
+		return err
+	}
+	err = c.window.AddScene("replay", c.newReplayScene())
+	if err != nil {
+		return err
+	}
+	err = c.window.Init(first, screenConfig)
 	if err != nil {
 		return err
 	}
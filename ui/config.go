@@ -0,0 +1,89 @@
+package ui
+
+import (
+	"image/color"
+
+	"github.com/miner/config"
+	"github.com/miner/logger"
+	"github.com/oakmound/oak/v4"
+	"github.com/oakmound/oak/v4/key"
+)
+
+// key bindings of the game and replay scenes, undo and redo are pressed with Ctrl
+var (
+	keyUndo  = key.Z
+	keyRedo  = key.Y
+	keyPause = key.Spacebar
+	keyStep  = key.RightArrow
+	keyHint  = key.H
+)
+
+// configure replaces the built-in presets, palette, window size and keys with the configured ones, the new presets are added.
+// The preset cell size is shrunk if the board does not fit into the window, unknown key names keep the default keys
+func configure(cfg config.Config, log logger.Logger) {
+	windowWidth, windowHeight = cfg.Window.Width, cfg.Window.Height
+	for name, s := range cfg.Sizes {
+		size := Size(name)
+		if size == sizeCustom {
+			log.Warn("config", "size %q is the board typed in the settings, the preset is skipped", name)
+			continue
+		}
+		gridSizes[size] = dimensions{s.Width, s.Height}
+		cellSize := s.CellSize
+		if fit := fitCellSize(s.Width, s.Height); fit < cellSize {
+			cellSize = fit
+		}
+		cellSizes[size] = cellSize
+	}
+	for name, percent := range cfg.Difficulties {
+		difficulties[Difficulty(name)] = percent
+	}
+
+	colors := map[string]*color.RGBA{
+		"green":    &green,
+		"yellow":   &yellow,
+		"red":      &red,
+		"dark_red": &darkRed,
+		"grey":     &grey,
+		"cyan":     &cyan,
+		"black":    &black,
+		"cell":     &cellColor,
+		"font":     &fontColor,
+	}
+	for name, c := range cfg.Colors {
+		if target, ok := colors[name]; ok {
+			*target = c
+		}
+	}
+
+	bindings := map[string]*key.Code{
+		"undo":  &keyUndo,
+		"redo":  &keyRedo,
+		"pause": &keyPause,
+		"step":  &keyStep,
+		"hint":  &keyHint,
+	}
+	codes := make(map[string]key.Code, len(key.AllKeys))
+	for code, name := range key.AllKeys {
+		codes[name] = code
+	}
+	for name, value := range cfg.Keys {
+		target, ok := bindings[name]
+		if !ok {
+			continue
+		}
+		code, ok := codes[value]
+		if !ok {
+			log.Warn("config", "unknown key %q for %s, keeping %s", value, name, key.AllKeys[*target])
+			continue
+		}
+		*target = code
+	}
+}
+
+// screenConfig sets the configured window size
+func screenConfig(c oak.Config) (oak.Config, error) {
+	c.Screen.Width = windowWidth
+	c.Screen.Height = windowHeight
+	return c, nil
+}
@@ -1,12 +1,16 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
 	"image"
 	"image/color"
 
+	"github.com/miner/game"
+	"github.com/oakmound/oak/v4"
 	"github.com/oakmound/oak/v4/collision"
 	"github.com/oakmound/oak/v4/event"
+	"github.com/oakmound/oak/v4/key"
 	"github.com/oakmound/oak/v4/mouse"
 	"github.com/oakmound/oak/v4/render"
 	"github.com/oakmound/oak/v4/scene"
@@ -16,61 +20,141 @@ const (
 	cellSizeSmall  = 20
 	cellSizeMedium = 25
 	cellSizeLarge  = 30
-	loseState      = "lose"
-	winState       = "win"
-	windowHeight   = 480
-	windowWidth    = 640
+	cellSizeMin    = 10
+	boardMargin    = 40
+)
+
+// windowWidth and windowHeight are set from the config before the window is opened
+var (
+	windowWidth  = 640
+	windowHeight = 480
 )
 
 type Grid struct {
-	size    int
-	cells   [][]*cellButton
-	cellMap map[int]*cellButton
+	width    int
+	height   int
+	cellSize int
+	cells    [][]*cellButton
+	cellMap  map[int]*cellButton
+}
+
+func (g *Grid) cell(x, y int) *cellButton {
+	return g.cellMap[x*g.height+y]
 }
 
-func calcOffset(gridSize int, cellSize int) Position {
+func newGrid(width, height, cellSize int) *Grid {
+	return &Grid{width: width, height: height, cellSize: cellSize,
+		cellMap: make(map[int]*cellButton, width*height)}
+}
+
+// newCellButtons creates the button of every cell of the grid, centered in the window
+func (c *Client) newCellButtons(ctx *scene.Context) {
+	grid := c.grid
+	cellSize := grid.cellSize
+	offset := calcOffset(grid.width, grid.height, cellSize)
+	for i := 0; i < grid.width; i++ {
+		for j := 0; j < grid.height; j++ {
+			p := Position{
+				offset.x + float64(i*cellSize),
+				offset.y + float64(j*cellSize),
+			}
+			s := Shape{
+				float64(cellSize - 1),
+				float64(cellSize - 1),
+			}
+			grid.cellMap[i*grid.height+j] = c.newCellButton(ctx, i, j, p, s, cellColor, grey, 3)
+		}
+	}
+}
+
+func calcOffset(width, height int, cellSize int) Position {
 	return Position{
-		0.5 * (windowWidth - float64(gridSize*cellSize)),
-		0.5 * (windowHeight - float64(gridSize*cellSize)),
+		0.5 * float64(windowWidth-width*cellSize),
+		0.5 * float64(windowHeight-height*cellSize),
 	}
 }
 
+// fitCellSize returns the largest cell size up to cellSizeLarge that fits the board into the window
+func fitCellSize(width, height int) int {
+	cellSize := cellSizeLarge
+	if s := (windowWidth - boardMargin) / width; s < cellSize {
+		cellSize = s
+	}
+	if s := (windowHeight - boardMargin) / height; s < cellSize {
+		cellSize = s
+	}
+	return cellSize
+}
+
 func (c *Client) newGameScene() scene.Scene {
-	fmt.Println("new game scene")
-	size := c.size.GridSize()
-	fmt.Println(size, c.difficulty.ToInt())
-	err := c.game.Start(size, c.difficulty.ToInt())
-	if err != nil {
-		c.log.Error("game", "Start: %v", err)
+	width, height := c.size.GridSize()
+	cellSize := cellSizes[c.size]
+	if c.resume {
+		c.resume = false
+		snapshot := c.game.Snapshot()
+		width, height = snapshot.Width, snapshot.Height
+		cellSize = fitCellSize(width, height)
+	} else {
+		opts := append([]game.Option{game.WithFirstClick(game.FirstClickOpening)}, c.startOpts...)
+		c.startOpts = nil
+		if c.noGuess {
+			opts = append(opts, game.WithNoGuess())
+		}
+		if c.size == sizeCustom {
+			var mines int
+			width, height, mines, _ = c.custom.parse()
+			cellSize = fitCellSize(width, height)
+			opts = append(opts, game.WithMines(mines))
+		}
+		c.log.Debug("game", "start %dx%d, difficulty %d", width, height, c.difficulty.ToInt())
+		err := c.game.StartRect(width, height, c.difficulty.ToInt(), opts...)
+		if err != nil {
+			c.log.Error("game", "Start: %v", err)
+		}
 	}
-	grid := &Grid{size: size,
-		cellMap: make(map[int]*cellButton, size*size)}
-	c.grid = grid
+	c.grid = newGrid(width, height, cellSize)
 
 	s := scene.Scene{
 		Start: func(ctx *scene.Context) {
-			size := c.size.GridSize()
-			grid := c.grid
-			cellSize := cellSizes[size]
-			offset := calcOffset(size, cellSize)
-			c.NewBackButton(ctx, Position{0, 0}, Shape{20, 480}, cyan, grey, 1)
-			for i := 0; i < size; i++ {
-				for j := 0; j < size; j++ {
-					p := Position{
-						offset.x + float64(i*cellSize),
-						offset.y + float64(j*cellSize),
-					}
-					s := Shape{
-						float64(cellSize - 1),
-						float64(cellSize - 1),
-					}
-					grid.cellMap[i*size+j] = c.newCellButton(ctx, i, j, p, s, cellColor, grey, 3)
-				}
+			c.result, c.summary, c.hints = nil, nil, nil
+			c.NewBackButton(ctx, Position{0, 0}, Shape{20, float64(windowHeight)}, cyan, grey, 1)
+			c.drawHeader(ctx)
+			c.newCellButtons(ctx)
+			c.drawBoard(ctx)
+			c.bindHistory(ctx)
+			c.bindHint(ctx)
+			if c.remote != nil {
+				c.bindRemote(ctx)
 			}
+			event.GlobalBind(ctx, oak.OnStop, func(struct{}) event.Response {
+				if c.remote != nil {
+					c.closeRemote()
+					return 0
+				}
+				c.saveGame()
+				return 0
+			})
 		}}
 	return s
 }
 
+// statusText renders the value computed on every frame
+type statusText func() string
+
+func (s statusText) String() string {
+	return s()
+}
+
+// drawHeader draws the live mine counter and timer above the board
+func (c *Client) drawHeader(ctx *scene.Context) {
+	ctx.DrawStack.Draw(c.font.NewStringerText(statusText(func() string {
+		return fmt.Sprintf("mines: %d", c.game.Remaining())
+	}), 40, 15))
+	ctx.DrawStack.Draw(c.font.NewStringerText(statusText(func() string {
+		return fmt.Sprintf("time: %03d", int(c.game.Elapsed().Seconds()))
+	}), float64(windowWidth-120), 15))
+}
+
 func (c *Client) newCellButton(ctx *scene.Context, ix, iy int, p Position, s Shape, clr, hclr color.RGBA, layer int) *cellButton {
 	hb := &cellButton{
 		button: button{
@@ -94,61 +178,49 @@ func (c *Client) newCellButton(ctx *scene.Context, ix, iy int, p Position, s Sha
 	render.Draw(hb.ColorBoxR, layer)
 
 	event.Bind(ctx, mouse.ClickOn, hb, func(box *cellButton, me *mouse.Event) event.Response {
-		size := c.size.GridSize()
-		if box.revealed {
+		cell, err := c.game.Cell(box.x, box.y)
+		if err != nil {
+			c.log.Error("game", "Cell: %v", err)
 			return 0
 		}
-		box.ColorBoxR.Color = image.NewUniform(color.RGBA{128, 128, 128, 128})
 		me.StopPropagation = true
-		cells, state, err := c.game.Reveal(hb.x, hb.y)
-		if err != nil {
-			c.log.Error("game", "Reveal: %v", err)
+		if c.replaying || c.game.State() != game.InProgress {
+			return 0
 		}
-		if state == loseState {
-			for _, cell := range cells {
-				cb := c.grid.cellMap[cell.X()*size+cell.Y()]
-				cb.revealed = true
-				if cell.HasBomb() {
-					cb.ColorBoxR.Color = image.NewUniform(red)
-					continue
-				}
-				cb.ColorBoxR.Color = image.NewUniform(grey)
-				if cell.Count() > 0 {
-					render.Draw(render.NewText(fmt.Sprintf("%d", cell.Count()), cb.Position.x+float64(cellSizes[size]/2-5), cb.Position.y+float64(cellSizes[size]/2-9)))
-				}
-			}
-			ctx.DrawStack.Draw(c.font.NewText("YOU LOSE!", 250, 15))
+		if me.Button == mouse.ButtonRight {
+			c.cycleMark(box, cell)
 			return 0
 		}
-		if state == winState {
-			for _, cell := range cells {
-				cb := c.grid.cellMap[cell.X()*size+cell.Y()]
-				cb.revealed = true
-				if cell.HasBomb() {
-					cb.ColorBoxR.Color = image.NewUniform(red)
-					continue
-				}
-				cb.ColorBoxR.Color = image.NewUniform(grey)
-				if cell.Count() > 0 {
-					render.Draw(render.NewText(fmt.Sprintf("%d", cell.Count()), cb.Position.x+float64(cellSizes[size]/2), cb.Position.y+float64(cellSizes[size]/2)))
-				}
+		if me.Button == mouse.ButtonMiddle || cell.State() == game.Revealed {
+			if cell.State() != game.Revealed {
+				return 0
 			}
-			ctx.DrawStack.Draw(c.font.NewText("CONGRATULATIONS!", 250, 15))
-			return 0
-		} else {
-			for _, cell := range cells {
-				cb := c.grid.cellMap[cell.X()*size+cell.Y()]
-				cb.revealed = true
-				cb.ColorBoxR.Color = image.NewUniform(black)
-				if cell.Count() > 0 {
-					render.Draw(render.NewText(fmt.Sprintf("%d", cell.Count()), cb.Position.x+float64(cellSizes[size]/2-5), cb.Position.y+float64(cellSizes[size]/2-9)))
-				}
+			cells, state, err := c.game.Chord(box.x, box.y)
+			if err != nil {
+				c.log.Error("game", "Chord: %v", err)
+				return 0
 			}
+			c.showCells(ctx, cells, state)
+			return 0
+		}
+		if cell.State() != game.Hidden && cell.State() != game.Question {
+			return 0
+		}
+		cells, state, err := c.game.Reveal(box.x, box.y)
+		if errors.Is(err, game.ErrGenerationBudget) {
+			c.log.Error("game", "Reveal: %v", err)
+			ctx.Window.GoToScene("error")
+			return 0
+		}
+		if err != nil {
+			c.log.Error("game", "Reveal: %v", err)
+			return 0
 		}
+		c.showCells(ctx, cells, state)
 		return 0
 	})
 	event.Bind(ctx, mouse.Start, hb, func(box *cellButton, me *mouse.Event) event.Response {
-		if box.revealed {
+		if !c.hidden(box) {
 			return 0
 		}
 		box.ColorBoxR.Color = image.NewUniform(box.hoverColor)
@@ -156,7 +228,7 @@ func (c *Client) newCellButton(ctx *scene.Context, ix, iy int, p Position, s Sha
 		return 0
 	})
 	event.Bind(ctx, mouse.Stop, hb, func(box *cellButton, me *mouse.Event) event.Response {
-		if box.revealed {
+		if !c.hidden(box) {
 			return 0
 		}
 		box.ColorBoxR.Color = image.NewUniform(clr)
@@ -165,3 +237,168 @@ func (c *Client) newCellButton(ctx *scene.Context, ix, iy int, p Position, s Sha
 	})
 	return hb
 }
+
+// hidden reports whether the cell of the button is not revealed yet and can still be played
+func (c *Client) hidden(box *cellButton) bool {
+	if c.replaying || c.game.State() != game.InProgress {
+		return false
+	}
+	cell, err := c.game.Cell(box.x, box.y)
+	if err != nil {
+		return false
+	}
+	switch cell.State() {
+	case game.Hidden, game.Flagged, game.Question:
+		return true
+	}
+	return false
+}
+
+// cycleMark switches the mark of the hidden cell: none -> flag -> question -> none
+func (c *Client) cycleMark(box *cellButton, cell game.Cell) {
+	var err error
+	switch cell.State() {
+	case game.Hidden:
+		err = c.game.Flag(box.x, box.y)
+	case game.Flagged:
+		err = c.game.Question(box.x, box.y)
+	case game.Question:
+		err = c.game.Unflag(box.x, box.y)
+	default:
+		return
+	}
+	if err != nil {
+		c.log.Error("game", "Mark: %v", err)
+		return
+	}
+	c.clearHint()
+	cell, err = c.game.Cell(box.x, box.y)
+	if err != nil {
+		c.log.Error("game", "Cell: %v", err)
+		return
+	}
+	c.drawCell(box, cell, false)
+}
+
+// drawBoard renders every cell from the game, used when the scene starts with the loaded game
+func (c *Client) drawBoard(ctx *scene.Context) {
+	c.clearHint()
+	state := c.game.State()
+	for _, column := range c.game.Board() {
+		for _, cell := range column {
+			c.drawCell(c.grid.cell(cell.X(), cell.Y()), cell, state != game.InProgress)
+		}
+	}
+	c.drawResult(ctx, state)
+}
+
+func (c *Client) showCells(ctx *scene.Context, cells []game.Cell, state game.GameState) {
+	c.clearHint()
+	for _, cell := range cells {
+		c.drawCell(c.grid.cell(cell.X(), cell.Y()), cell, state != game.InProgress)
+	}
+	c.drawResult(ctx, state)
+	if state == game.Win {
+		c.recordScore(c.game)
+	}
+	if state != game.InProgress && c.remote == nil {
+		c.saveReplay()
+	}
+}
+
+// drawResult shows the message of the finished game, the win adds 3BV/s and efficiency under the board.
+// The messages are removed when the end of the game is undone
+func (c *Client) drawResult(ctx *scene.Context, state game.GameState) {
+	for _, r := range []render.Renderable{c.result, c.summary} {
+		if r != nil {
+			r.Undraw()
+		}
+	}
+	c.result, c.summary = nil, nil
+	switch state {
+	case game.Lose:
+		c.result, _ = ctx.DrawStack.Draw(c.font.NewText("YOU LOSE!", 250, 15))
+	case game.Win:
+		c.result, _ = ctx.DrawStack.Draw(c.font.NewText("CONGRATULATIONS!", 250, 15))
+		c.summary, _ = ctx.DrawStack.Draw(c.font.NewText(summary(c.game), 40, float64(windowHeight-20)))
+	}
+}
+
+// summary returns the 3BV, 3BV per second and the efficiency, the share of 3BV in all clicks
+func summary(g game.Game) string {
+	bbbv := g.BBBV()
+	text := fmt.Sprintf("3BV: %d", bbbv)
+	if seconds := g.Elapsed().Seconds(); seconds > 0 {
+		text += fmt.Sprintf("  3BV/s: %.2f", float64(bbbv)/seconds)
+	}
+	if clicks := g.Counters().Total(); clicks > 0 {
+		text += fmt.Sprintf("  efficiency: %.0f%%", 100*float64(bbbv)/float64(clicks))
+	}
+	return text
+}
+
+// bindHistory binds Ctrl+Z to undo and Ctrl+Y to redo the last move, the board is redrawn after both
+func (c *Client) bindHistory(ctx *scene.Context) {
+	event.GlobalBind(ctx, key.AnyDown, func(e key.Event) event.Response {
+		if e.Modifiers&key.ModControl == 0 {
+			return 0
+		}
+		var err error
+		switch e.Code {
+		case keyUndo:
+			_, _, err = c.game.Undo()
+		case keyRedo:
+			_, _, err = c.game.Redo()
+		default:
+			return 0
+		}
+		if err != nil {
+			c.log.Error("game", "History: %v", err)
+			return 0
+		}
+		c.drawBoard(ctx)
+		return 0
+	})
+}
+
+// drawCell renders the cell button from the cell state. Hidden cells are greyed out once the game is over, the game keeps their counts hidden
+func (c *Client) drawCell(cb *cellButton, cell game.Cell, over bool) {
+	if cb.marker != nil {
+		cb.marker.Undraw()
+		cb.marker = nil
+	}
+	cellSize := c.grid.cellSize
+	switch cell.State() {
+	case game.Hidden:
+		cb.ColorBoxR.Color = image.NewUniform(cb.color)
+		if over {
+			cb.ColorBoxR.Color = image.NewUniform(grey)
+		}
+	case game.Revealed:
+		cb.ColorBoxR.Color = image.NewUniform(black)
+		c.drawCount(cb, cell)
+	case game.Flagged, game.WrongFlag:
+		cb.ColorBoxR.Color = image.NewUniform(cb.color)
+		if cell.State() == game.WrongFlag {
+			cb.ColorBoxR.Color = image.NewUniform(yellow)
+		}
+		flag := render.NewColorBoxR(cellSize/3, cellSize/3, red)
+		flag.SetPos(cb.Position.x+float64(cellSize/3), cb.Position.y+float64(cellSize/3))
+		cb.marker, _ = render.Draw(flag, 4)
+	case game.Question:
+		cb.ColorBoxR.Color = image.NewUniform(cb.color)
+		cb.marker, _ = render.Draw(render.NewText("?", cb.Position.x+float64(cellSize/2-5), cb.Position.y+float64(cellSize/2-9)), 4)
+	case game.Mine:
+		cb.ColorBoxR.Color = image.NewUniform(red)
+	case game.Exploded:
+		cb.ColorBoxR.Color = image.NewUniform(darkRed)
+	}
+}
+
+func (c *Client) drawCount(cb *cellButton, cell game.Cell) {
+	if cell.Count() == 0 {
+		return
+	}
+	cellSize := c.grid.cellSize
+	cb.marker, _ = render.Draw(render.NewText(fmt.Sprintf("%d", cell.Count()), cb.Position.x+float64(cellSize/2-5), cb.Position.y+float64(cellSize/2-9)), 4)
+}
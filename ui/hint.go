@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"image/color"
+
+	"github.com/miner/game"
+	"github.com/oakmound/oak/v4/event"
+	"github.com/oakmound/oak/v4/key"
+	"github.com/oakmound/oak/v4/render"
+	"github.com/oakmound/oak/v4/scene"
+)
+
+// bindHint shows the hint on keyHint: the hinted cell is marked green if safe, red if a mine and yellow for a guess,
+// the cells of the reason are marked cyan and the reason is written under the board. The marks are removed on the next move
+func (c *Client) bindHint(ctx *scene.Context) {
+	event.GlobalBind(ctx, key.AnyDown, func(e key.Event) event.Response {
+		if e.Code != keyHint || e.Modifiers&key.ModControl != 0 || c.replaying {
+			return 0
+		}
+		h, err := c.game.Hint()
+		if err != nil {
+			c.log.Error("game", "Hint: %v", err)
+			return 0
+		}
+		c.showHint(ctx, h)
+		return 0
+	})
+}
+
+func (c *Client) showHint(ctx *scene.Context, h game.Hint) {
+	c.clearHint()
+	clr := green
+	switch {
+	case h.Guess:
+		clr = yellow
+	case h.Mine:
+		clr = darkRed
+	}
+	c.hintMark(h.X, h.Y, clr)
+	for _, cell := range h.Because {
+		c.hintMark(cell.X(), cell.Y(), cyan)
+	}
+	text, _ := ctx.DrawStack.Draw(c.font.NewText(h.Reason, 40, float64(windowHeight-20)))
+	c.hints = append(c.hints, text)
+}
+
+// hintMark draws the small box in the corner of the cell
+func (c *Client) hintMark(x, y int, clr color.RGBA) {
+	cb := c.grid.cell(x, y)
+	size := c.grid.cellSize / 4
+	mark := render.NewColorBoxR(size, size, clr)
+	mark.SetPos(cb.Position.x+2, cb.Position.y+2)
+	r, _ := render.Draw(mark, 5)
+	c.hints = append(c.hints, r)
+}
+
+func (c *Client) clearHint() {
+	for _, r := range c.hints {
+		r.Undraw()
+	}
+	c.hints = nil
+}
@@ -0,0 +1,71 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/miner/server"
+	"github.com/oakmound/oak/v4/event"
+	"github.com/oakmound/oak/v4/scene"
+)
+
+// RunRemote opens the window with the multiplayer game joined on the server, the settings scene is skipped
+func (c *Client) RunRemote(r *server.Remote) error {
+	c.remote = r
+	c.game = r
+	c.size = sizeCustom
+	c.resume = true
+	if err := c.window.AddScene("game", c.newGameScene()); err != nil {
+		return err
+	}
+	return c.run("game")
+}
+
+// bindRemote redraws the board on the moves of the other players and shows the progress of the room under the board
+func (c *Client) bindRemote(ctx *scene.Context) {
+	r := c.remote
+	ctx.DrawStack.Draw(c.font.NewStringerText(statusText(func() string {
+		return roomStatus(r)
+	}), 40, float64(windowHeight-40)))
+	event.GlobalBind(ctx, event.Enter, func(event.EnterPayload) event.Response {
+		select {
+		case <-r.Changes():
+			c.drawBoard(ctx)
+		default:
+		}
+		return 0
+	})
+}
+
+// closeRemote leaves the multiplayer game, the next game is played locally
+func (c *Client) closeRemote() {
+	if c.remote == nil {
+		return
+	}
+	if err := c.remote.Close(); err != nil {
+		c.log.Error("remote", "Close: %v", err)
+	}
+	c.remote = nil
+}
+
+// roomStatus returns the room, its players with the revealed cells and the winner of the race
+func roomStatus(r *server.Remote) string {
+	if !r.Connected() {
+		return "disconnected"
+	}
+	room, mode := r.Room()
+	players, winner := r.Players()
+	names := make([]string, 0, len(players))
+	for _, p := range players {
+		name := fmt.Sprintf("%s %d/%d", p.Name, p.Revealed, p.Safe)
+		if !p.Connected {
+			name += " (away)"
+		}
+		names = append(names, name)
+	}
+	text := fmt.Sprintf("%s %s: %s", mode, room, strings.Join(names, ", "))
+	if winner != "" {
+		text += "  winner: " + winner
+	}
+	return text
+}
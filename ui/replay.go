@@ -0,0 +1,208 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/miner/game"
+	"github.com/oakmound/oak/v4/event"
+	"github.com/oakmound/oak/v4/key"
+	"github.com/oakmound/oak/v4/scene"
+)
+
+const (
+	replayDirName = "replays"
+	replayExt     = ".jsonl"
+)
+
+func replayDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "miner", replayDirName), nil
+}
+
+// latestReplay returns the path of the most recent replay, the file names sort by the end time of the game
+func latestReplay() (string, error) {
+	dir, err := replayDir()
+	if err != nil {
+		return "", err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), replayExt) {
+			names = append(names, entry.Name())
+		}
+	}
+	if len(names) == 0 {
+		return "", os.ErrNotExist
+	}
+	sort.Strings(names)
+	return filepath.Join(dir, names[len(names)-1]), nil
+}
+
+// hasReplay reports whether there is the finished game to play back
+func hasReplay() bool {
+	_, err := latestReplay()
+	return err == nil
+}
+
+// saveReplay writes the replay of the finished game next to the previous ones
+func (c *Client) saveReplay() {
+	dir, err := replayDir()
+	if err != nil {
+		c.log.Error("replay", "replayDir: %v", err)
+		return
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		c.log.Error("replay", "MkdirAll: %v", err)
+		return
+	}
+	name := c.game.EndedAt().Format("20060102-150405.000") + replayExt
+	f, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		c.log.Error("replay", "Create: %v", err)
+		return
+	}
+	defer f.Close()
+	if err := c.game.Replay().Write(f); err != nil {
+		c.log.Error("replay", "Write: %v", err)
+	}
+}
+
+// loadReplay reads the replay file, the most recent one if the path is empty
+func loadReplay(path string) (game.Replay, error) {
+	if path == "" {
+		var err error
+		path, err = latestReplay()
+		if err != nil {
+			return game.Replay{}, err
+		}
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return game.Replay{}, err
+	}
+	defer f.Close()
+	return game.ReadReplay(f)
+}
+
+// player drives the game with the replay steps. The game clock follows the playback time, so the timer shows the recorded time
+type player struct {
+	replay  game.Replay
+	base    time.Time
+	elapsed time.Duration
+	speed   int
+	paused  bool
+	next    int
+}
+
+func (p *player) now() time.Time {
+	return p.base.Add(p.elapsed)
+}
+
+func (p *player) String() string {
+	status := fmt.Sprintf("replay %dx  step %d/%d", p.speed, p.next, len(p.replay.Steps))
+	if p.paused {
+		status += "  paused"
+	}
+	return status
+}
+
+// step applies the next move and moves the playback time to it
+func (p *player) step(g game.Game) error {
+	s := p.replay.Steps[p.next]
+	p.next++
+	if s.At > p.elapsed {
+		p.elapsed = s.At
+	}
+	_, _, err := s.Apply(g)
+	return err
+}
+
+func (p *player) done() bool {
+	return p.next >= len(p.replay.Steps)
+}
+
+// newReplayScene plays back the chosen replay file or the most recent finished game. Space pauses, the right arrow steps while paused, 1, 2 and 4 set the speed
+func (c *Client) newReplayScene() scene.Scene {
+	return scene.Scene{
+		Start: func(ctx *scene.Context) {
+			c.NewBackButton(ctx, Position{0, 0}, Shape{20, float64(windowHeight)}, cyan, grey, 1)
+			replay, err := loadReplay(c.replayPath)
+			if err != nil {
+				c.log.Error("replay", "loadReplay: %v", err)
+				ctx.DrawStack.Draw(c.font.NewText("No replay", 40, 15))
+				return
+			}
+			p := &player{replay: replay, base: time.Now(), speed: 1}
+			g := game.NewGame()
+			if err := replay.Start(g, game.WithClock(p.now)); err != nil {
+				c.log.Error("replay", "Start: %v", err)
+				ctx.DrawStack.Draw(c.font.NewText("Bad replay", 40, 15))
+				return
+			}
+			c.game = g
+			c.replaying = true
+			c.result, c.summary = nil, nil
+			c.grid = newGrid(replay.Width, replay.Height, fitCellSize(replay.Width, replay.Height))
+			c.drawHeader(ctx)
+			ctx.DrawStack.Draw(c.font.NewStringerText(p, 40, float64(windowHeight-25)))
+			c.newCellButtons(ctx)
+			c.drawBoard(ctx)
+
+			event.GlobalBind(ctx, event.Enter, func(e event.EnterPayload) event.Response {
+				if p.paused || p.done() {
+					return 0
+				}
+				p.elapsed += e.SinceLastFrame * time.Duration(p.speed)
+				applied := false
+				for !p.done() && p.replay.Steps[p.next].At <= p.elapsed {
+					if err := p.step(g); err != nil {
+						c.log.Error("replay", "Apply: %v", err)
+					}
+					applied = true
+				}
+				if applied {
+					c.drawBoard(ctx)
+				}
+				return 0
+			})
+			event.GlobalBind(ctx, key.AnyDown, func(e key.Event) event.Response {
+				switch e.Code {
+				case keyPause:
+					p.paused = !p.paused
+				case keyStep:
+					if !p.paused || p.done() {
+						return 0
+					}
+					if err := p.step(g); err != nil {
+						c.log.Error("replay", "Apply: %v", err)
+					}
+					c.drawBoard(ctx)
+				case key.Num1:
+					p.speed = 1
+				case key.Num2:
+					p.speed = 2
+				case key.Num4:
+					p.speed = 4
+				}
+				return 0
+			})
+		},
+		End: func() (string, *scene.Result) {
+			c.replaying = false
+			c.replayPath = ""
+			return "settings", nil
+		},
+	}
+}
@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/miner/game"
+)
+
+const saveFileName = "save.json"
+
+func savePath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "miner", saveFileName), nil
+}
+
+// hasSave reports whether there is the saved game to continue
+func hasSave() bool {
+	path, err := savePath()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// saveGame writes the game in progress to disk, a finished or not started game removes the save
+func (c *Client) saveGame() {
+	path, err := savePath()
+	if err != nil {
+		c.log.Error("save", "savePath: %v", err)
+		return
+	}
+	if c.game.State() != game.InProgress || c.game.Moves() == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			c.log.Error("save", "Remove: %v", err)
+		}
+		return
+	}
+	var buf bytes.Buffer
+	if err := c.game.Save(&buf); err != nil {
+		c.log.Error("save", "Save: %v", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		c.log.Error("save", "MkdirAll: %v", err)
+		return
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		c.log.Error("save", "WriteFile: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		c.log.Error("save", "Rename: %v", err)
+	}
+}
+
+// loadGame replaces the client game with the saved one
+func (c *Client) loadGame() error {
+	path, err := savePath()
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	g := game.NewGame()
+	if err := g.Load(f); err != nil {
+		return err
+	}
+	c.game = g
+	c.resume = true
+	return nil
+}
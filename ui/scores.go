@@ -0,0 +1,79 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/miner/game"
+	"github.com/miner/internal/sysuser"
+	"github.com/miner/scores"
+	"github.com/oakmound/oak/v4/scene"
+)
+
+const (
+	scoresPerBoard = 5
+	scoresLineStep = 22
+)
+
+// recordScore saves the won game to the high-score table in the background. Games with undone moves or hints are skipped
+func (c *Client) recordScore(g game.Game) {
+	if c.scores == nil || !g.Eligible() {
+		return
+	}
+	board := scores.Board{Width: c.grid.width, Height: c.grid.height, Mines: g.Mines()}
+	entry := scores.Entry{
+		Name: sysuser.Name(),
+		Time: g.Elapsed(),
+		Date: g.EndedAt(),
+		BBBV: g.BBBV(),
+		Seed: g.Seed(),
+	}
+	go func() {
+		rank, err := c.scores.Add(board, entry)
+		if err != nil {
+			c.log.Error("scores", "Add: %v", err)
+			return
+		}
+		if rank >= 0 {
+			c.log.Info("scores", "%v new record #%d: %v", board, rank+1, entry.Time)
+		}
+	}()
+}
+
+func (c *Client) newScoresScene() scene.Scene {
+	return scene.Scene{Start: func(ctx *scene.Context) {
+		c.NewBackButton(ctx, Position{0, 0}, Shape{20, float64(windowHeight)}, cyan, grey, 1)
+		y := float64(scoresLineStep)
+		if c.scores == nil {
+			ctx.DrawStack.Draw(c.font.NewText("No scores", 40, y))
+			return
+		}
+		boards, err := c.scores.Boards()
+		if err != nil {
+			c.log.Error("scores", "Boards: %v", err)
+		}
+		if len(boards) == 0 {
+			ctx.DrawStack.Draw(c.font.NewText("No scores", 40, y))
+			return
+		}
+		for _, board := range boards {
+			if y > float64(windowHeight-scoresLineStep) {
+				return
+			}
+			ctx.DrawStack.Draw(c.font.NewText(board.String(), 40, y))
+			y += scoresLineStep
+			entries, err := c.scores.Top(board)
+			if err != nil {
+				c.log.Error("scores", "Top: %v", err)
+				continue
+			}
+			for i, entry := range entries {
+				if i >= scoresPerBoard || y > float64(windowHeight-scoresLineStep) {
+					break
+				}
+				line := fmt.Sprintf("%d. %-12s %7.2fs  3BV %-4d %s", i+1, entry.Name, entry.Time.Seconds(), entry.BBBV, entry.Date.Format("2006-01-02"))
+				ctx.DrawStack.Draw(c.font.NewText(line, 60, y))
+				y += scoresLineStep
+			}
+		}
+	}}
+}
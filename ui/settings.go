@@ -1,12 +1,16 @@
 package ui
 
 import (
+	"errors"
 	"image"
 	"image/color"
+	"sort"
+	"strconv"
 
 	"github.com/miner/game"
 	"github.com/oakmound/oak/v4/collision"
 	"github.com/oakmound/oak/v4/event"
+	"github.com/oakmound/oak/v4/key"
 	"github.com/oakmound/oak/v4/mouse"
 	"github.com/oakmound/oak/v4/render"
 	"github.com/oakmound/oak/v4/scene"
@@ -21,8 +25,10 @@ func (s Size) String() string {
 	return string(s)
 }
 
-func (s Size) GridSize() int {
-	return gridSizes[s]
+// GridSize returns the width and height of the board
+func (s Size) GridSize() (int, int) {
+	d := gridSizes[s]
+	return d.width, d.height
 }
 
 type Difficulty string
@@ -43,6 +49,8 @@ const (
 	sizeSmall  Size = "small"
 	sizeMedium Size = "medium"
 	sizeLarge  Size = "large"
+	sizeExpert Size = "expert"
+	sizeCustom Size = "custom"
 
 	difficultyEasy   Difficulty = "easy"
 	difficultyNormal Difficulty = "normal"
@@ -50,15 +58,17 @@ const (
 )
 
 var (
-	gridSizes = map[Size]int{
-		sizeSmall:  10,
-		sizeMedium: 14,
-		sizeLarge:  20,
+	gridSizes = map[Size]dimensions{
+		sizeSmall:  {10, 10},
+		sizeMedium: {14, 14},
+		sizeLarge:  {20, 20},
+		sizeExpert: {30, 16},
 	}
-	cellSizes = map[int]int{
-		10: cellSizeLarge,
-		14: cellSizeMedium,
-		20: cellSizeSmall,
+	cellSizes = map[Size]int{
+		sizeSmall:  cellSizeLarge,
+		sizeMedium: cellSizeMedium,
+		sizeLarge:  cellSizeSmall,
+		sizeExpert: cellSizeSmall,
 	}
 	difficulties = map[Difficulty]int{
 		difficultyEasy:   10,
@@ -66,11 +76,13 @@ var (
 		difficultyHard:   30,
 	}
 	sizeButtons       = make(map[Size]*sizeButton)
+	inputFields       = make(map[string]*inputField)
 	difficultyButtons = make(map[Difficulty]*difficultyButton)
 
 	green     = color.RGBA{178, 222, 39, 1}
 	yellow    = color.RGBA{249, 215, 28, 1}
 	red       = color.RGBA{236, 100, 75, 1}
+	darkRed   = color.RGBA{150, 30, 20, 255}
 	grey      = color.RGBA{128, 128, 128, 128}
 	cyan      = color.RGBA{20, 205, 200, 1}
 	black     = color.RGBA{0, 0, 0, 0}
@@ -97,6 +109,7 @@ func (c *Client) NewBackButton(ctx *scene.Context, p Position, s Shape, color, h
 		c.size = ""
 		c.difficulty = ""
 		c.grid = nil
+		c.closeRemote()
 		ctx.Window.GoToScene("settings")
 		return 0
 	})
@@ -112,6 +125,42 @@ func (c *Client) NewBackButton(ctx *scene.Context, p Position, s Shape, color, h
 	})
 }
 
+type dimensions struct {
+	width, height int
+}
+
+const maxInputLength = 3
+
+var errBadCustomBoard = errors.New("bad custom board")
+
+// customBoard holds the width, height and mines typed by the player for the custom size
+type customBoard struct {
+	width, height, mines string
+}
+
+// parse validates the typed values, the board must fit into the window and have at least one free cell
+func (b customBoard) parse() (int, int, int, error) {
+	width, err := strconv.Atoi(b.width)
+	if err != nil {
+		return 0, 0, 0, errBadCustomBoard
+	}
+	height, err := strconv.Atoi(b.height)
+	if err != nil {
+		return 0, 0, 0, errBadCustomBoard
+	}
+	mines, err := strconv.Atoi(b.mines)
+	if err != nil {
+		return 0, 0, 0, errBadCustomBoard
+	}
+	if width <= 0 || height <= 0 || mines <= 0 || mines >= width*height {
+		return 0, 0, 0, errBadCustomBoard
+	}
+	if fitCellSize(width, height) < cellSizeMin {
+		return 0, 0, 0, errBadCustomBoard
+	}
+	return width, height, mines, nil
+}
+
 type Position struct {
 	x, y float64
 }
@@ -184,7 +233,16 @@ func (c *Client) newStartButton(ctx *scene.Context, p Position, s Shape, color,
 
 	event.Bind(ctx, mouse.ClickOn, hb, func(box *startButton, me *mouse.Event) event.Response {
 		me.StopPropagation = true
-		if c.difficulty.undefined() || c.size.undefined() {
+		if c.size.undefined() {
+			ctx.Window.GoToScene("error")
+			return 0
+		}
+		if c.size == sizeCustom {
+			if _, _, _, err := c.custom.parse(); err != nil {
+				ctx.Window.GoToScene("error")
+				return 0
+			}
+		} else if c.difficulty.undefined() {
 			ctx.Window.GoToScene("error")
 			return 0
 		}
@@ -205,6 +263,80 @@ func (c *Client) newStartButton(ctx *scene.Context, p Position, s Shape, color,
 	})
 }
 
+// newSceneButton creates the button that switches the window to the given scene
+func (c *Client) newSceneButton(ctx *scene.Context, p Position, s Shape, color, hoverColor color.RGBA, layer int, label, name string) {
+	var text render.Renderable
+	sb := &sceneButton{
+		button: button{color: color, hoverColor: hoverColor},
+		scene:  name,
+	}
+	sb.id = ctx.Register(sb)
+	sb.ColorBoxR = render.NewColorBoxR(int(s.width), int(s.height), color)
+	sb.ColorBoxR.SetPos(p.x, p.y)
+	sp := collision.NewSpace(p.x, p.y, s.width, s.height, sb.id)
+	sp.SetZLayer(float64(layer))
+	mouse.Add(sp)
+	mouse.PhaseCollision(sp, ctx.Handler)
+	render.Draw(sb.ColorBoxR, layer)
+
+	event.Bind(ctx, mouse.ClickOn, sb, func(sb *sceneButton, me *mouse.Event) event.Response {
+		me.StopPropagation = true
+		ctx.Window.GoToScene(sb.scene)
+		return 0
+	})
+	event.Bind(ctx, mouse.Start, sb, func(sb *sceneButton, me *mouse.Event) event.Response {
+		sb.ColorBoxR.Color = image.NewUniform(hoverColor)
+		me.StopPropagation = true
+		text, _ = render.Draw(c.font.NewText(label, p.x+s.width/2-20, p.y+s.height/2-10))
+		return 0
+	})
+	event.Bind(ctx, mouse.Stop, sb, func(sb *sceneButton, me *mouse.Event) event.Response {
+		sb.ColorBoxR.Color = image.NewUniform(color)
+		me.StopPropagation = true
+		text.Undraw()
+		return 0
+	})
+}
+
+// newContinueButton creates the button that loads the saved game and opens it
+func (c *Client) newContinueButton(ctx *scene.Context, p Position, s Shape, color, hoverColor color.RGBA, layer int) {
+	var text render.Renderable
+	hb := &startButton{
+		button{color: color, hoverColor: hoverColor},
+	}
+	hb.id = ctx.Register(hb)
+	hb.ColorBoxR = render.NewColorBoxR(int(s.width), int(s.height), color)
+	hb.ColorBoxR.SetPos(p.x, p.y)
+	sp := collision.NewSpace(p.x, p.y, s.width, s.height, hb.id)
+	sp.SetZLayer(float64(layer))
+	mouse.Add(sp)
+	mouse.PhaseCollision(sp, ctx.Handler)
+	render.Draw(hb.ColorBoxR, layer)
+
+	event.Bind(ctx, mouse.ClickOn, hb, func(box *startButton, me *mouse.Event) event.Response {
+		me.StopPropagation = true
+		if err := c.loadGame(); err != nil {
+			c.log.Error("save", "loadGame: %v", err)
+			ctx.Window.GoToScene("error")
+			return 0
+		}
+		ctx.Window.NextScene()
+		return 0
+	})
+	event.Bind(ctx, mouse.Start, hb, func(box *startButton, me *mouse.Event) event.Response {
+		box.ColorBoxR.Color = image.NewUniform(hoverColor)
+		me.StopPropagation = true
+		text, _ = render.Draw(c.font.NewText("Continue", p.x+s.width/2-40, p.y+s.height/2-10))
+		return 0
+	})
+	event.Bind(ctx, mouse.Stop, hb, func(box *startButton, me *mouse.Event) event.Response {
+		box.ColorBoxR.Color = image.NewUniform(color)
+		me.StopPropagation = true
+		text.Undraw()
+		return 0
+	})
+}
+
 func (c *Client) newDifficultyButton(ctx *scene.Context, p Position, s Shape, color, hoverColor color.RGBA, layer int, diff Difficulty, m map[Difficulty]*difficultyButton) {
 	var text render.Renderable
 	sb := &difficultyButton{
@@ -262,10 +394,110 @@ func (c *Client) newDifficultyButton(ctx *scene.Context, p Position, s Shape, co
 	})
 }
 
+// newInputField creates the numeric field for the custom board. Click focuses the field, digits and backspace edit the value
+func (c *Client) newInputField(ctx *scene.Context, p Position, s Shape, color, hoverColor color.RGBA, layer int, label string, value *string) {
+	f := &inputField{
+		button: button{
+			color:      color,
+			hoverColor: hoverColor,
+		},
+		label: label,
+		value: value,
+	}
+	inputFields[label] = f
+	f.id = ctx.Register(f)
+	f.ColorBoxR = render.NewColorBoxR(int(s.width), int(s.height), color)
+	f.ColorBoxR.SetPos(p.x, p.y)
+
+	sp := collision.NewSpace(p.x, p.y, s.width, s.height, f.id)
+	sp.SetZLayer(float64(layer))
+
+	mouse.Add(sp)
+	mouse.PhaseCollision(sp, ctx.Handler)
+
+	render.Draw(f.ColorBoxR, layer)
+	render.Draw(c.font.NewStringerText(f, p.x+10, p.y+s.height/2-10), layer+1)
+
+	event.Bind(ctx, mouse.ClickOn, f, func(f *inputField, me *mouse.Event) event.Response {
+		me.StopPropagation = true
+		for _, field := range inputFields {
+			field.focused = false
+			field.ColorBoxR.Color = image.NewUniform(field.color)
+		}
+		f.focused = true
+		f.ColorBoxR.Color = image.NewUniform(f.hoverColor)
+		return 0
+	})
+	event.Bind(ctx, mouse.Start, f, func(f *inputField, me *mouse.Event) event.Response {
+		f.ColorBoxR.Color = image.NewUniform(f.hoverColor)
+		me.StopPropagation = true
+		return 0
+	})
+	event.Bind(ctx, mouse.Stop, f, func(f *inputField, me *mouse.Event) event.Response {
+		if !f.focused {
+			f.ColorBoxR.Color = image.NewUniform(f.color)
+		}
+		me.StopPropagation = true
+		return 0
+	})
+	event.GlobalBind(ctx, key.AnyDown, func(e key.Event) event.Response {
+		if !f.focused {
+			return 0
+		}
+		switch {
+		case e.Code == key.DeleteBackspace && len(*f.value) > 0:
+			*f.value = (*f.value)[:len(*f.value)-1]
+		case e.Rune >= '0' && e.Rune <= '9' && len(*f.value) < maxInputLength:
+			*f.value += string(e.Rune)
+		}
+		return 0
+	})
+}
+
+// newToggleButton creates the button that switches the setting on and off, the label shows the current value
+func (c *Client) newToggleButton(ctx *scene.Context, p Position, s Shape, color, hoverColor color.RGBA, layer int, label string, value *bool) {
+	t := &toggleButton{
+		button: button{
+			color:      color,
+			hoverColor: hoverColor,
+		},
+		label: label,
+		value: value,
+	}
+	t.id = ctx.Register(t)
+	t.ColorBoxR = render.NewColorBoxR(int(s.width), int(s.height), color)
+	t.ColorBoxR.SetPos(p.x, p.y)
+
+	sp := collision.NewSpace(p.x, p.y, s.width, s.height, t.id)
+	sp.SetZLayer(float64(layer))
+
+	mouse.Add(sp)
+	mouse.PhaseCollision(sp, ctx.Handler)
+
+	render.Draw(t.ColorBoxR, layer)
+	render.Draw(c.font.NewStringerText(t, p.x+10, p.y+s.height/2-10), layer+1)
+
+	event.Bind(ctx, mouse.ClickOn, t, func(t *toggleButton, me *mouse.Event) event.Response {
+		me.StopPropagation = true
+		*t.value = !*t.value
+		return 0
+	})
+	event.Bind(ctx, mouse.Start, t, func(t *toggleButton, me *mouse.Event) event.Response {
+		t.ColorBoxR.Color = image.NewUniform(t.hoverColor)
+		me.StopPropagation = true
+		return 0
+	})
+	event.Bind(ctx, mouse.Stop, t, func(t *toggleButton, me *mouse.Event) event.Response {
+		t.ColorBoxR.Color = image.NewUniform(t.color)
+		me.StopPropagation = true
+		return 0
+	})
+}
+
 func (c *Client) NewErrorScene() scene.Scene {
 	return scene.Scene{Start: func(ctx *scene.Context) {
 		ctx.DrawStack.Draw(c.font.NewText("Bad input!", 210, 240))
-		c.NewBackButton(ctx, Position{0, 0}, Shape{20, 480}, cyan, grey, 1)
+		c.NewBackButton(ctx, Position{0, 0}, Shape{20, float64(windowHeight)}, cyan, grey, 1)
 	}}
 
 }
@@ -273,34 +505,99 @@ func (c *Client) NewErrorScene() scene.Scene {
 //func (c *Client) NewWinScene() scene.Scene {
 //	return scene.Scene{Start: func(ctx *scene.Context) {
 //		ctx.DrawStack.Draw(c.font.NewText("CONGRATULATIONS!", 250, 240))
-//		c.NewBackButton(ctx, Position{0, 0}, Shape{20, 480}, cyan, grey, 1)
+//		c.NewBackButton(ctx, Position{0, 0}, Shape{20, float64(windowHeight)}, cyan, grey, 1)
 //	}}
 //}
 
 //func (c *Client) NewLoseScene() scene.Scene {
 //	return scene.Scene{Start: func(ctx *scene.Context) {
 //		ctx.DrawStack.Draw(c.font.NewText("YOU LOSE!", 250, 240))
-//		c.NewBackButton(ctx, Position{0, 0}, Shape{20, 480}, cyan, grey, 1)
+//		c.NewBackButton(ctx, Position{0, 0}, Shape{20, float64(windowHeight)}, cyan, grey, 1)
 //	}}
 //}
 
+// settingsTop and settingsRow are the position of the first button row and the distance of the rows in the settings scene
+const (
+	settingsTop = 50
+	settingsRow = 52
+)
+
+// presetSizes returns the board presets from the smallest board, the custom size is not included
+func presetSizes() []Size {
+	sizes := make([]Size, 0, len(gridSizes))
+	for size := range gridSizes {
+		sizes = append(sizes, size)
+	}
+	sort.Slice(sizes, func(i, j int) bool {
+		a, b := gridSizes[sizes[i]], gridSizes[sizes[j]]
+		if a.width*a.height != b.width*b.height {
+			return a.width*a.height < b.width*b.height
+		}
+		return sizes[i] < sizes[j]
+	})
+	return sizes
+}
+
+// presetDifficulties returns the difficulties from the easiest one
+func presetDifficulties() []Difficulty {
+	diffs := make([]Difficulty, 0, len(difficulties))
+	for diff := range difficulties {
+		diffs = append(diffs, diff)
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		if difficulties[diffs[i]] != difficulties[diffs[j]] {
+			return difficulties[diffs[i]] < difficulties[diffs[j]]
+		}
+		return diffs[i] < diffs[j]
+	})
+	return diffs
+}
+
 func (c *Client) newSettingScene() scene.Scene {
 	return scene.Scene{
 		Start: func(ctx *scene.Context) {
-			s := Shape{200, 50}
-			c.newSizeButton(ctx, Position{119, 50}, s, green, grey, 1, sizeSmall, sizeButtons)
-			c.newSizeButton(ctx, Position{119, 102}, s, yellow, grey, 1, sizeMedium, sizeButtons)
-			c.newSizeButton(ctx, Position{119, 154}, s, red, grey, 1, sizeLarge, sizeButtons)
+			// the sizes with the custom one and the toggle are on the left, the difficulties with the custom board fields
+			// on the right, the rows shrink when the configured presets do not fit into the window
+			sizes, diffs := presetSizes(), presetDifficulties()
+			rows := len(sizes) + 2
+			if len(diffs)+3 > rows {
+				rows = len(diffs) + 3
+			}
+			step := float64(settingsRow)
+			if fit := float64(windowHeight-settingsTop-settingsRow/5) / float64(rows+2); fit < step {
+				step = fit
+			}
+			s := Shape{200, step - 2}
+			row := func(i int) float64 { return settingsTop + float64(i)*step }
+			palette := []color.RGBA{green, yellow, red, cyan}
 
-			c.newDifficultyButton(ctx, Position{321, 50}, s, green, grey, 1, difficultyEasy, difficultyButtons)
-			c.newDifficultyButton(ctx, Position{321, 102}, s, yellow, grey, 1, difficultyNormal, difficultyButtons)
-			c.newDifficultyButton(ctx, Position{321, 154}, s, red, grey, 1, difficultyHard, difficultyButtons)
+			for i, size := range sizes {
+				c.newSizeButton(ctx, Position{119, row(i)}, s, palette[i%len(palette)], grey, 1, size, sizeButtons)
+			}
+			c.newSizeButton(ctx, Position{119, row(len(sizes))}, s, palette[len(sizes)%len(palette)], grey, 1, sizeCustom, sizeButtons)
+			c.newToggleButton(ctx, Position{119, row(len(sizes) + 1)}, s, red, grey, 1, "no guess", &c.noGuess)
 
-			c.newStartButton(ctx, Position{119, 206}, Shape{402, 50}, cyan, grey, 1)
+			for i, diff := range diffs {
+				c.newDifficultyButton(ctx, Position{321, row(i)}, s, palette[i%len(palette)], grey, 1, diff, difficultyButtons)
+			}
+			c.newInputField(ctx, Position{321, row(len(diffs))}, s, yellow, grey, 1, "width", &c.custom.width)
+			c.newInputField(ctx, Position{321, row(len(diffs) + 1)}, s, yellow, grey, 1, "height", &c.custom.height)
+			c.newInputField(ctx, Position{321, row(len(diffs) + 2)}, s, yellow, grey, 1, "mines", &c.custom.mines)
+
+			c.newStartButton(ctx, Position{119, row(rows)}, s, cyan, grey, 1)
+			if hasSave() {
+				c.newContinueButton(ctx, Position{321, row(rows)}, s, green, grey, 1)
+			}
+			c.newSceneButton(ctx, Position{119, row(rows + 1)}, s, yellow, grey, 1, "Scores", "scores")
+			if hasReplay() {
+				c.newSceneButton(ctx, Position{321, row(rows + 1)}, s, cyan, grey, 1, "Replay", "replay")
+			}
 		},
 		End: func() (string, *scene.Result) {
-			g := game.NewGame()
-			c.game = g
+			if !c.resume {
+				g := game.NewGame()
+				c.game = g
+			}
 			c.window.AddScene("game", c.newGameScene())
 			return "game", nil //set the next scene to "game"
 		},